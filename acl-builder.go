@@ -0,0 +1,131 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import "context"
+
+// xmlSchemaInstance is the namespace of the xsi:type attribute
+// carried by every ACL Grantee element.
+const xmlSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+
+// ACLBuilder - Provides chainable construction of an
+// AccessControlPolicyEncode with correctly typed grantees.
+type ACLBuilder struct {
+	owner  Owner
+	grants []GrantEncode
+}
+
+// NewACLBuilder - Instantiate a new ACL builder for the given owner.
+func NewACLBuilder(owner Owner) *ACLBuilder {
+	return &ACLBuilder{owner: owner}
+}
+
+// newGranteeEncode returns a grantee of the given type with the
+// XML schema instance attributes set.
+func newGranteeEncode(granteeType string) GranteeEncode {
+	return GranteeEncode{
+		XMLNS:  xmlSchemaInstance,
+		XMLXSI: granteeType,
+		Type:   granteeType,
+	}
+}
+
+// GrantCanonicalUser - Grants perm to the canonical user id.
+func (b *ACLBuilder) GrantCanonicalUser(id, perm string) *ACLBuilder {
+	grantee := newGranteeEncode(GranteeTypeCanonicalUser)
+	grantee.ID = id
+	b.grants = append(b.grants, GrantEncode{Grantee: grantee, Permission: perm})
+	return b
+}
+
+// GrantEmail - Grants perm to the AWS account with the given email address.
+func (b *ACLBuilder) GrantEmail(email, perm string) *ACLBuilder {
+	grantee := newGranteeEncode(GranteeTypeEmail)
+	grantee.Email = email
+	b.grants = append(b.grants, GrantEncode{Grantee: grantee, Permission: perm})
+	return b
+}
+
+// GrantGroup - Grants perm to the predefined group identified by uri.
+func (b *ACLBuilder) GrantGroup(uri, perm string) *ACLBuilder {
+	grantee := newGranteeEncode(GranteeTypeGroup)
+	grantee.URI = uri
+	b.grants = append(b.grants, GrantEncode{Grantee: grantee, Permission: perm})
+	return b
+}
+
+// GrantDisplayName - Grants perm to the canonical user known by its
+// display name only. The canonical ID is resolved by BuildResolved; Build
+// leaves the grantee without ID, and such a policy is rejected when set.
+func (b *ACLBuilder) GrantDisplayName(displayName, perm string) *ACLBuilder {
+	grantee := newGranteeEncode(GranteeTypeCanonicalUser)
+	grantee.DisplayName = displayName
+	b.grants = append(b.grants, GrantEncode{Grantee: grantee, Permission: perm})
+	return b
+}
+
+// DisplayNameResolver - Returns the canonical ID of the user with the
+// given display name, e.g. Client.CanonicalIDForDisplayName.
+type DisplayNameResolver func(ctx context.Context, displayName string) (string, error)
+
+// BuildResolved - Returns the policy assembled so far like Build, with
+// the canonical IDs of the grantees added by GrantDisplayName resolved
+// through resolve. Each display name is resolved once.
+func (b *ACLBuilder) BuildResolved(ctx context.Context, resolve DisplayNameResolver) (*AccessControlPolicyEncode, error) {
+	policy := b.Build()
+	ids := make(map[string]string)
+	for i := range policy.AccessControlList.Grants {
+		grantee := &policy.AccessControlList.Grants[i].Grantee
+		if grantee.ID != "" || grantee.DisplayName == "" || grantee.Type != GranteeTypeCanonicalUser {
+			continue
+		}
+		id, ok := ids[grantee.DisplayName]
+		if !ok {
+			var err error
+			if id, err = resolve(ctx, grantee.DisplayName); err != nil {
+				return nil, err
+			}
+			ids[grantee.DisplayName] = id
+		}
+		grantee.ID = id
+	}
+	return policy, nil
+}
+
+// Build - Returns the policy assembled so far. Further calls on the
+// builder do not modify the returned policy.
+func (b *ACLBuilder) Build() *AccessControlPolicyEncode {
+	grants := make([]GrantEncode, len(b.grants))
+	copy(grants, b.grants)
+	return &AccessControlPolicyEncode{
+		Owner:             b.owner,
+		AccessControlList: AccessControlListEncode{Grants: grants},
+	}
+}
+
+// LogDeliveryACL - Returns the bucket ACL required by S3 server access
+// logging on a target bucket: FULL_CONTROL for owner, WRITE and READ_ACP
+// for the LogDelivery group. It is the log-delivery-write canned ACL,
+// ready to be passed to PutBucketAcl.
+func LogDeliveryACL(owner Owner) *AccessControlPolicyEncode {
+	return NewACLBuilder(owner).
+		GrantCanonicalUser(owner.ID, PermissionFullControl).
+		GrantGroup(GroupLogDelivery, PermissionWrite).
+		GrantGroup(GroupLogDelivery, PermissionReadACP).
+		Build()
+}
@@ -0,0 +1,138 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestACLBuilder(t *testing.T) {
+	owner := Owner{ID: "owner-id", DisplayName: "owner"}
+
+	testCases := []struct {
+		build func(b *ACLBuilder) *ACLBuilder
+		xml   string
+	}{
+		// Test 1: canonical user grant.
+		{
+			func(b *ACLBuilder) *ACLBuilder { return b.GrantCanonicalUser("abc123", "FULL_CONTROL") },
+			`<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner-id</ID><DisplayName>owner</DisplayName></Owner><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>abc123</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+		},
+		// Test 2: email grant.
+		{
+			func(b *ACLBuilder) *ACLBuilder { return b.GrantEmail("user@example.com", "READ") },
+			`<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner-id</ID><DisplayName>owner</DisplayName></Owner><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="AmazonCustomerByEmail"><EmailAddress>user@example.com</EmailAddress></Grantee><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+		},
+		// Test 3: group grant.
+		{
+			func(b *ACLBuilder) *ACLBuilder {
+				return b.GrantGroup("http://acs.amazonaws.com/groups/global/AllUsers", "READ")
+			},
+			`<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner-id</ID><DisplayName>owner</DisplayName></Owner><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+		},
+		// Test 4: no grants.
+		{
+			func(b *ACLBuilder) *ACLBuilder { return b },
+			`<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner-id</ID><DisplayName>owner</DisplayName></Owner><AccessControlList></AccessControlList></AccessControlPolicy>`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		policy := testCase.build(NewACLBuilder(owner)).Build()
+		buf, err := xml.Marshal(policy)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if string(buf) != testCase.xml {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.xml, buf)
+		}
+		for _, g := range policy.AccessControlList.Grants {
+			if g.Grantee.Type != g.Grantee.XMLXSI {
+				t.Errorf("Test %d: grantee type %q does not match xsi:type %q", i+1, g.Grantee.Type, g.Grantee.XMLXSI)
+			}
+		}
+	}
+}
+
+func TestACLBuilderBuildIsolated(t *testing.T) {
+	b := NewACLBuilder(Owner{ID: "owner-id"}).GrantCanonicalUser("abc123", "READ")
+	policy := b.Build()
+	b.GrantCanonicalUser("def456", "WRITE")
+	if n := len(policy.AccessControlList.Grants); n != 1 {
+		t.Fatalf("expected built policy to keep 1 grant, got %d", n)
+	}
+}
+
+func TestLogDeliveryACL(t *testing.T) {
+	policy := LogDeliveryACL(Owner{ID: "owner-id", DisplayName: "owner"})
+	buf, err := xml.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner-id</ID><DisplayName>owner</DisplayName></Owner><AccessControlList>` +
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>owner-id</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>` +
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI></Grantee><Permission>WRITE</Permission></Grant>` +
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI></Grantee><Permission>READ_ACP</Permission></Grant>` +
+		`</AccessControlList></AccessControlPolicy>`
+	if string(buf) != expected {
+		t.Fatalf("expected %s, got %s", expected, buf)
+	}
+	if canned := CannedACLName(policy, ""); canned != "log-delivery-write" {
+		t.Fatalf("expected log-delivery-write, got %q", canned)
+	}
+}
+
+func TestACLBuilderBuildResolved(t *testing.T) {
+	lookups := 0
+	resolve := func(ctx context.Context, displayName string) (string, error) {
+		lookups++
+		if displayName == "alice" {
+			return "alice-id", nil
+		}
+		return "", ErrDisplayNameNotResolved
+	}
+	b := NewACLBuilder(Owner{ID: "owner-id"}).
+		GrantCanonicalUser("owner-id", PermissionFullControl).
+		GrantDisplayName("alice", PermissionRead).
+		GrantDisplayName("alice", PermissionWriteACP)
+
+	// Build leaves the grantee unresolved.
+	if id := b.Build().AccessControlList.Grants[1].Grantee.ID; id != "" {
+		t.Fatalf("expected no ID before resolution, got %q", id)
+	}
+	policy, err := b.BuildResolved(context.Background(), resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range policy.AccessControlList.Grants[1:] {
+		if g.Grantee.ID != "alice-id" || g.Grantee.DisplayName != "alice" || g.Grantee.Type != GranteeTypeCanonicalUser {
+			t.Fatalf("unexpected grantee %+v", g.Grantee)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("expected a single lookup, got %d", lookups)
+	}
+
+	_, err = b.GrantDisplayName("bob", PermissionRead).BuildResolved(context.Background(), resolve)
+	if !errors.Is(err, ErrDisplayNameNotResolved) {
+		t.Fatalf("expected ErrDisplayNameNotResolved, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// bucketACLCacheSize is the maximum number of bucket ACLs cached by a
+// client created with Options.BucketACLCacheTTL.
+const bucketACLCacheSize = 1000
+
+// ACLCache - Memoizes decoded object and bucket ACLs for a limited time, to avoid
+// fetching the same ACL repeatedly, e.g. while checking several
+// permissions on an object. It is safe for concurrent use.
+//
+// The cache is not aware of ACL changes made by other clients; an ACL
+// may be served up to TTL after it changed. Changes made through the
+// cache owner should be followed by Invalidate.
+type ACLCache struct {
+	client  *Client
+	ttl     time.Duration
+	maxSize int
+
+	// mutex protects items.
+	mutex sync.Mutex
+	items map[string]aclCacheEntry
+
+	// now returns the current time, overridden by tests.
+	now func() time.Time
+}
+
+// aclCacheEntry is a cached ACL along with its expiry time.
+type aclCacheEntry struct {
+	policy  *AccessControlPolicyDecode
+	expires time.Time
+}
+
+// NewACLCache - Instantiate a new ACL cache fetching ACLs through the
+// client c. ACLs are kept for ttl and at most maxSize ACLs are kept;
+// a non-positive maxSize means no bound.
+func NewACLCache(c *Client, ttl time.Duration, maxSize int) *ACLCache {
+	return &ACLCache{
+		client:  c,
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[string]aclCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// aclCacheKey returns the cache key of a bucket, or of an object when
+// objectName is non-empty.
+func aclCacheKey(bucketName, objectName string) string {
+	return bucketName + "/" + objectName
+}
+
+// GetObjectACL - Returns the decoded ACL of an object, from the cache
+// when a fresh entry exists. The returned policy may be modified by the
+// caller without affecting the cache.
+func (r *ACLCache) GetObjectACL(ctx context.Context, bucketName, objectName string) (*AccessControlPolicyDecode, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, err
+	}
+	return r.get(ctx, bucketName, objectName)
+}
+
+// GetBucketACL - Returns the decoded ACL of a bucket, from the cache
+// when a fresh entry exists. The returned policy may be modified by the
+// caller without affecting the cache.
+func (r *ACLCache) GetBucketACL(ctx context.Context, bucketName string) (*AccessControlPolicyDecode, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, err
+	}
+	return r.get(ctx, bucketName, "")
+}
+
+// get returns the ACL of a bucket, or of an object when objectName is
+// non-empty, fetching and caching it when needed.
+func (r *ACLCache) get(ctx context.Context, bucketName, objectName string) (*AccessControlPolicyDecode, error) {
+	key := aclCacheKey(bucketName, objectName)
+	r.mutex.Lock()
+	entry, ok := r.items[key]
+	r.mutex.Unlock()
+	if ok && r.now().Before(entry.expires) {
+		return entry.policy.Clone(), nil
+	}
+
+	acld, err := r.client.getACLPolicy(ctx, bucketName, objectName, "", nil, ACLOptions{})
+	if err != nil {
+		return nil, err
+	}
+	r.set(key, acld.Clone())
+	return acld, nil
+}
+
+// set stores an ACL, evicting the entries closest to expiry when the
+// cache is full.
+func (r *ACLCache) set(key string, acld *AccessControlPolicyDecode) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	if _, ok := r.items[key]; !ok && r.maxSize > 0 {
+		for k, entry := range r.items {
+			if !now.Before(entry.expires) {
+				delete(r.items, k)
+			}
+		}
+		for len(r.items) >= r.maxSize {
+			var oldest string
+			for k, entry := range r.items {
+				if oldest == "" || entry.expires.Before(r.items[oldest].expires) {
+					oldest = k
+				}
+			}
+			delete(r.items, oldest)
+		}
+	}
+	r.items[key] = aclCacheEntry{policy: acld, expires: now.Add(r.ttl)}
+}
+
+// Invalidate - Drops the cached ACL of an object, if any.
+func (r *ACLCache) Invalidate(bucketName, objectName string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.items, aclCacheKey(bucketName, objectName))
+}
+
+// InvalidateBucket - Drops the cached ACL of a bucket, if any. The
+// cached ACLs of its objects are kept.
+func (r *ACLCache) InvalidateBucket(bucketName string) {
+	r.Invalidate(bucketName, "")
+}
+
+// Len - Returns the number of cached ACLs, expired ones included until
+// they get evicted.
+func (r *ACLCache) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.items)
+}
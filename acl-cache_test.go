@@ -0,0 +1,156 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newACLCacheTest(t *testing.T, objects ...string) (*aclTestServer, *ACLCache, *time.Time, func()) {
+	stub := newACLTestServer()
+	for _, object := range objects {
+		stub.acls["/bucket/"+object+"?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	}
+	srv := httptest.NewServer(stub)
+	cache := NewACLCache(newACLTestClient(t, srv), time.Minute, 2)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	return stub, cache, &now, srv.Close
+}
+
+func TestACLCacheTTL(t *testing.T) {
+	stub, cache, now, done := newACLCacheTest(t, "object")
+	defer done()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		acld, err := cache.GetObjectACL(ctx, "bucket", "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Modifying the result does not affect the cache.
+		acld.AccessControlList.Grants[0].Permission = PermissionRead
+	}
+	if n := stub.count(http.MethodGet); n != 1 {
+		t.Fatalf("expected 1 GET request, got %d", n)
+	}
+
+	*now = now.Add(time.Minute)
+	acld, err := cache.GetObjectACL(ctx, "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodGet); n != 2 {
+		t.Fatalf("expected the expired entry to be fetched again, got %d GET requests", n)
+	}
+	if acld.AccessControlList.Grants[0].Permission != PermissionFullControl {
+		t.Fatalf("unexpected grants %+v", acld.AccessControlList.Grants)
+	}
+
+	// Errors are not cached.
+	for i := 0; i < 2; i++ {
+		if _, err = cache.GetObjectACL(ctx, "bucket", "missing"); ToErrorResponse(err).Code != "NoSuchKey" {
+			t.Fatalf("expected NoSuchKey, got %v", err)
+		}
+	}
+	if n := stub.count(http.MethodGet); n != 4 {
+		t.Fatalf("expected 4 GET requests, got %d", n)
+	}
+}
+
+func TestACLCacheEviction(t *testing.T) {
+	stub, cache, now, done := newACLCacheTest(t, "a", "b", "c")
+	defer done()
+	ctx := context.Background()
+
+	for _, object := range []string{"a", "b", "c"} {
+		if _, err := cache.GetObjectACL(ctx, "bucket", object); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+	}
+	if n := cache.Len(); n != 2 {
+		t.Fatalf("expected 2 cached ACLs, got %d", n)
+	}
+	// "a" was evicted, "b" and "c" are still cached.
+	for _, object := range []string{"b", "c", "a"} {
+		if _, err := cache.GetObjectACL(ctx, "bucket", object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := stub.count(http.MethodGet); n != 4 {
+		t.Fatalf("expected 4 GET requests, got %d", n)
+	}
+}
+
+func TestACLCacheInvalidate(t *testing.T) {
+	stub, cache, _, done := newACLCacheTest(t, "object")
+	defer done()
+	ctx := context.Background()
+
+	if _, err := cache.GetObjectACL(ctx, "bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	stub.mu.Lock()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testGroupGrantXML(GroupAllUsers, "READ"))
+	stub.mu.Unlock()
+
+	cache.Invalidate("bucket", "object")
+	acld, err := cache.GetObjectACL(ctx, "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(acld.AccessControlList.Grants); n != 2 {
+		t.Fatalf("expected the updated ACL with 2 grants, got %d", n)
+	}
+	if n := stub.count(http.MethodGet); n != 2 {
+		t.Fatalf("expected 2 GET requests, got %d", n)
+	}
+}
+
+func TestACLCacheConcurrent(t *testing.T) {
+	objects := make([]string, 5)
+	for i := range objects {
+		objects[i] = fmt.Sprintf("object-%d", i)
+	}
+	_, cache, _, done := newACLCacheTest(t, objects...)
+	defer done()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			object := objects[i%len(objects)]
+			if _, err := cache.GetObjectACL(context.Background(), "bucket", object); err != nil {
+				t.Error(err)
+			}
+			cache.Invalidate("bucket", object)
+		}(i)
+	}
+	wg.Wait()
+	if n := cache.Len(); n > 2 {
+		t.Fatalf("cache exceeds its size bound: %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrACLResponseTooLarge is returned when an ACL response body exceeds
+// ACLOptions.MaxResponseSize, MaxACLResponseSize by default.
+var ErrACLResponseTooLarge = errors.New("ACL response exceeds the maximum allowed size")
+
+// ErrMalformedACL matches, through errors.Is, the MalformedACLError
+// returned by S3 when an ACL document does not validate. Retrying the
+// same document will not help.
+var ErrMalformedACL = errors.New("malformed ACL")
+
+// ErrEmptyACLGrants is returned, when GetObjectACLOptions.FailOnEmptyGrants
+// is set, for an ACL without any grant. S3 never returns such an ACL, it
+// usually means the response was not decoded properly.
+var ErrEmptyACLGrants = errors.New("ACL response has no grants")
+
+// ErrMultipleACLOwners is returned when an ACL response carries more
+// than one Owner element, which no S3 server should send. See
+// ACLOptions.AllowMultipleOwners.
+var ErrMultipleACLOwners = errors.New("ACL response has more than one Owner")
+
+// ErrCustomACL is returned by GetObjectCannedACL when the grants of an
+// ACL do not match any canned ACL.
+var ErrCustomACL = errors.New("ACL does not match a canned ACL")
+
+// ErrTruncatedACLResponse is returned when an ACL response body ends
+// before the AccessControlPolicy document is complete, typically because
+// the connection was closed mid-body. A malformed but complete document
+// yields an XML syntax error instead.
+var ErrTruncatedACLResponse = errors.New("ACL response is truncated")
+
+// ErrNotAnACLResponse is returned when the response to an ACL request is
+// not an AccessControlPolicy document, e.g. the object body returned by
+// a proxy which stripped the acl query parameter.
+var ErrNotAnACLResponse = errors.New("response is not an ACL document")
+
+// ErrDisplayNameNotResolved is returned by CanonicalIDForDisplayName when
+// the display name does not match a canonical user it can see.
+var ErrDisplayNameNotResolved = errors.New("display name cannot be resolved to a canonical ID")
+
+// ErrEmptyACLPermission is returned for an ACL response holding a grant
+// without a Permission element, see ACLOptions.SkipEmptyPermission.
+var ErrEmptyACLPermission = errors.New("ACL grant has no permission")
+
+// ErrUnknownACLPermission is returned, when GetObjectACLOptions.UnknownPermission
+// is UnknownPermissionError, for a grant whose permission is not one of
+// the S3 permissions.
+var ErrUnknownACLPermission = errors.New("ACL grant has an unknown permission")
+
+// ErrNoSuchBucket matches, through errors.Is, the NoSuchBucket error
+// returned by S3 when the bucket does not exist.
+var ErrNoSuchBucket = errors.New("bucket does not exist")
+
+// ErrNoSuchKey matches, through errors.Is, the NoSuchKey error returned
+// by S3 when the object does not exist, e.g. by GetObjectACL.
+var ErrNoSuchKey = errors.New("object does not exist")
+
+// ErrObjectLockACLImmutable matches, through errors.Is, the ObjectLocked
+// error returned when the ACL of an object under governance or
+// compliance retention cannot be changed. Bulk operations can use it to
+// skip locked objects.
+var ErrObjectLockACLImmutable = errors.New("object is locked, its ACL cannot be changed")
+
+// ErrExistingACLGrants is returned by PutBucketACLWithOptions, when
+// PutBucketACLOptions.IfNoExistingGrants is set, for a bucket whose ACL
+// already holds grants besides the FULL_CONTROL grant of its owner.
+var ErrExistingACLGrants = errors.New("ACL already has grants besides the owner's")
+
+// ErrACLNotSupported matches, through errors.Is, the
+// AccessControlListNotSupported error returned by servers or buckets
+// with ACLs disabled, e.g. with the BucketOwnerEnforced object
+// ownership. Bulk operations can use it to skip such buckets.
+var ErrACLNotSupported = errors.New("ACLs are not supported")
+
+// errorCodeSentinels maps exported sentinel errors to the S3 error code
+// an ErrorResponse must carry to match them with errors.Is.
+var errorCodeSentinels = map[error]string{
+	ErrMalformedACL: "MalformedACLError",
+	ErrNoSuchBucket: "NoSuchBucket",
+	ErrNoSuchKey:    "NoSuchKey",
+
+	ErrObjectLockACLImmutable: "ObjectLocked",
+	ErrACLNotSupported:        "AccessControlListNotSupported",
+}
+
+// ACLRequestError wraps the error of an ACL request with the request
+// method, resource and query parameters, e.g. the acl and versionId
+// parameters. The underlying error, usually an ErrorResponse, is
+// available through errors.As and ToErrorResponse.
+type ACLRequestError struct {
+	Method     string
+	BucketName string
+	ObjectName string
+	Query      url.Values
+	Err        error
+}
+
+// Error - Returns the request and the underlying error.
+func (e *ACLRequestError) Error() string {
+	return fmt.Sprintf("%s /%s/%s?%s: %v", e.Method, e.BucketName, e.ObjectName, e.Query.Encode(), e.Err)
+}
+
+// Unwrap - Returns the underlying error.
+func (e *ACLRequestError) Unwrap() error {
+	return e.Err
+}
+
+// newACLRequestError wraps err, returned by the ACL request described by
+// method and metadata, in an ACLRequestError.
+func newACLRequestError(method string, metadata requestMetadata, err error) error {
+	return &ACLRequestError{
+		Method:     method,
+		BucketName: metadata.bucketName,
+		ObjectName: metadata.objectName,
+		Query:      metadata.queryValues,
+		Err:        err,
+	}
+}
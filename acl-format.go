@@ -0,0 +1,86 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// groupNames holds the friendly names of the well-known group URIs.
+var groupNames = map[string]string{
+	GroupAllUsers:           "AllUsers",
+	GroupAuthenticatedUsers: "AuthenticatedUsers",
+	GroupLogDelivery:        "LogDelivery",
+}
+
+// formatIdentity renders a canonical user as "name (id=...)", or as
+// "id=..." when the display name is unknown.
+func formatIdentity(id, displayName string) string {
+	if displayName == "" {
+		return "id=" + id
+	}
+	return displayName + " (id=" + id + ")"
+}
+
+// formatGrantee renders a grantee for FormatACL.
+func formatGrantee(g GranteeDecode) string {
+	switch {
+	case g.URI != "":
+		if name, ok := groupNames[g.URI]; ok {
+			return name
+		}
+		return g.URI
+	case g.Email != "":
+		return g.Email + " (email)"
+	default:
+		return formatIdentity(g.ID, g.DisplayName)
+	}
+}
+
+// FormatACL writes a human-readable summary of an ACL to w, one line
+// for the owner followed by one line per grant, e.g.
+//
+//	Owner: Alice (id=...)
+//	Grant: AllUsers READ
+//
+// Well-known group URIs are rendered by name.
+func FormatACL(w io.Writer, acld *AccessControlPolicyDecode) error {
+	if acld == nil {
+		_, err := io.WriteString(w, "<nil>\n")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Owner: %s\n", formatIdentity(acld.Owner.ID, acld.Owner.DisplayName)); err != nil {
+		return err
+	}
+	for _, g := range acld.AccessControlList.Grants {
+		if _, err := fmt.Fprintf(w, "Grant: %s %s\n", formatGrantee(g.Grantee), g.Permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the summary written by FormatACL, without the
+// trailing newline.
+func (acld *AccessControlPolicyDecode) String() string {
+	var sb strings.Builder
+	FormatACL(&sb, acld)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
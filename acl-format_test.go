@@ -0,0 +1,81 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the ACL format tests")
+
+func TestFormatACL(t *testing.T) {
+	grant := func(grantee GranteeDecode, perm string) GrantDecode {
+		return GrantDecode{Grantee: grantee, Permission: perm}
+	}
+	policy := func(owner Owner, grants ...GrantDecode) *AccessControlPolicyDecode {
+		acld := &AccessControlPolicyDecode{Owner: owner}
+		acld.AccessControlList.Grants = grants
+		return acld
+	}
+	testCases := []struct {
+		name string
+		acld *AccessControlPolicyDecode
+	}{
+		{"canonical-user", policy(Owner{ID: "owner-id", DisplayName: "Alice"},
+			grant(GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "owner-id", DisplayName: "Alice"}, PermissionFullControl),
+			grant(GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "bob-id"}, PermissionRead))},
+		{"email", policy(Owner{ID: "owner-id"},
+			grant(GranteeDecode{Type: GranteeTypeEmail, Email: "carol@example.com"}, PermissionReadACP))},
+		{"groups", policy(Owner{ID: "owner-id", DisplayName: "Alice"},
+			grant(GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}, PermissionRead),
+			grant(GranteeDecode{Type: GranteeTypeGroup, URI: GroupAuthenticatedUsers}, PermissionWrite),
+			grant(GranteeDecode{Type: GranteeTypeGroup, URI: GroupLogDelivery}, PermissionWriteACP),
+			grant(GranteeDecode{Type: GranteeTypeGroup, URI: "http://acs.example.com/groups/Custom"}, PermissionRead))},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			acld := testCase.acld
+			var buf bytes.Buffer
+			if err := FormatACL(&buf, acld); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "acl-format", testCase.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(want) {
+				t.Fatalf("expected\n%s\ngot\n%s", want, buf.String())
+			}
+			if acld.String() != strings.TrimSuffix(string(want), "\n") {
+				t.Fatalf("unexpected String() %q", acld.String())
+			}
+		})
+	}
+}
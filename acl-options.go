@@ -0,0 +1,221 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ACLOptions holds the options shared by the ACL operations taking an
+// options argument.
+type ACLOptions struct {
+	// Timeout, when non-zero, bounds the whole operation, retries
+	// included, on top of any deadline of the context.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a failed request is retried.
+	// Retries follow the client rules: network errors, the 429, 499,
+	// 500, 502, 503 and 504 statuses, and the retryable S3 error codes,
+	// such as RequestTimeout, SlowDown or InternalError, are retried;
+	// so is a request redirected to another region. Zero keeps the
+	// client default, see MaxRetry; a negative value disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the base delay between two attempts, doubled on
+	// every retry. Zero keeps the client default, see DefaultRetryUnit.
+	RetryBackoff time.Duration
+
+	// DisableContentMD5 omits the Content-MD5 header sent with ACL
+	// documents, e.g. where MD5 is not allowed. The header is sent by
+	// default.
+	DisableContentMD5 bool
+
+	// UsePOST sends ACL updates with POST instead of PUT, for gateways
+	// expecting POST ?acl. The request is otherwise identical.
+	UsePOST bool
+
+	// Chunked sends ACL documents with Transfer-Encoding: chunked and no
+	// Content-Length header, for signing proxies preferring chunked
+	// bodies. Documents are sent with a fixed length by default.
+	Chunked bool
+
+	// SchemaNamespace overrides the xmlns of the AccessControlPolicy
+	// documents marshaled by the client, for gateways requiring a
+	// custom schema URI. It defaults to the S3 namespace,
+	// http://s3.amazonaws.com/doc/2006-03-01/. Raw XML documents are
+	// sent as is.
+	SchemaNamespace string
+
+	// AllowMultipleOwners keeps the first Owner of an ACL response
+	// carrying several, instead of failing with ErrMultipleACLOwners.
+	// The occurrence is written to the trace output when tracing is on.
+	AllowMultipleOwners bool
+
+	// SkipEmptyPermission drops the grants of an ACL response without a
+	// Permission element, instead of failing with ErrEmptyACLPermission.
+	SkipEmptyPermission bool
+
+	// MaxResponseSize is the maximum size in bytes of an ACL response
+	// body, larger responses fail with ErrACLResponseTooLarge. Zero
+	// keeps the default, MaxACLResponseSize.
+	MaxResponseSize int64
+
+	// ExtraHeaders are added to every request of the operation. Headers
+	// set by the client itself, such as Authorization, and headers
+	// without a value are rejected. Only the first value of a header is
+	// sent.
+	ExtraHeaders http.Header
+
+	// ExpectedBucketOwner, when set, is sent as the
+	// x-amz-expected-bucket-owner header so that the request fails if
+	// the bucket belongs to another account.
+	ExpectedBucketOwner string
+
+	// OnComplete, when set, is called after every ACL request of the
+	// operation, successful or not, e.g. to record metrics.
+	OnComplete func(info ACLCallInfo)
+}
+
+// ACLCallInfo describes a completed ACL request, see ACLOptions.OnComplete.
+type ACLCallInfo struct {
+	Method     string
+	BucketName string
+	ObjectName string
+
+	// StatusCode is the HTTP status of the response, zero when no
+	// response was received.
+	StatusCode int
+
+	// Bytes is the size of the ACL document sent or received.
+	Bytes int64
+
+	// Duration is the time spent on the request, retries included.
+	Duration time.Duration
+
+	// Err is the error of the request, if any.
+	Err error
+}
+
+// onComplete calls opts.OnComplete for a request started at start.
+func (opts ACLOptions) onComplete(method string, metadata requestMetadata, resp *http.Response, n int64, start time.Time, err error) {
+	info := ACLCallInfo{
+		Method:     method,
+		BucketName: metadata.bucketName,
+		ObjectName: metadata.objectName,
+		Bytes:      n,
+		Duration:   time.Since(start),
+		Err:        err,
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	opts.OnComplete(info)
+}
+
+// reservedACLHeaders is the set of headers computed by the client that
+// ACLOptions.ExtraHeaders cannot override.
+var reservedACLHeaders = map[string]bool{
+	"Authorization":        true,
+	"Host":                 true,
+	"Content-Length":       true,
+	"Content-Md5":          true,
+	"X-Amz-Content-Sha256": true,
+	"X-Amz-Date":           true,
+	"X-Amz-Security-Token": true,
+}
+
+// setHeaders returns customHeader merged with the extra headers of
+// opts. customHeader is not modified.
+func (opts ACLOptions) setHeaders(customHeader http.Header) (http.Header, error) {
+	if len(opts.ExtraHeaders) == 0 && opts.ExpectedBucketOwner == "" {
+		return customHeader, nil
+	}
+	header := customHeader.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for k, v := range opts.ExtraHeaders {
+		k = http.CanonicalHeaderKey(k)
+		if reservedACLHeaders[k] {
+			return nil, errInvalidArgument(fmt.Sprintf("Header %s cannot be set through ExtraHeaders.", k))
+		}
+		if len(v) == 0 || v[0] == "" {
+			return nil, errInvalidArgument(fmt.Sprintf("Header %s has no value in ExtraHeaders.", k))
+		}
+		header[k] = append([]string(nil), v...)
+	}
+	if opts.ExpectedBucketOwner != "" {
+		header.Set("X-Amz-Expected-Bucket-Owner", opts.ExpectedBucketOwner)
+	}
+	return header, nil
+}
+
+// putMethod returns the HTTP method used to update an ACL.
+func (opts ACLOptions) putMethod() string {
+	if opts.UsePOST {
+		return http.MethodPost
+	}
+	return http.MethodPut
+}
+
+// marshalPolicy marshals an ACL policy, in the SchemaNamespace
+// namespace when set.
+func (opts ACLOptions) marshalPolicy(acle *AccessControlPolicyEncode) ([]byte, error) {
+	if opts.SchemaNamespace == "" {
+		return xml.Marshal(acle)
+	}
+	var buf bytes.Buffer
+	start := xml.StartElement{Name: xml.Name{Space: opts.SchemaNamespace, Local: "AccessControlPolicy"}}
+	if err := xml.NewEncoder(&buf).EncodeElement(acle, start); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// maxResponseSize returns the maximum size of an ACL response body.
+func (opts ACLOptions) maxResponseSize() int64 {
+	if opts.MaxResponseSize <= 0 {
+		return MaxACLResponseSize
+	}
+	return opts.MaxResponseSize
+}
+
+// setRetry applies the retry options to a request.
+func (opts ACLOptions) setRetry(metadata *requestMetadata) {
+	switch {
+	case opts.MaxRetries < 0:
+		metadata.maxRetry = 1
+	case opts.MaxRetries > 0:
+		metadata.maxRetry = opts.MaxRetries + 1
+	}
+	metadata.retryUnit = opts.RetryBackoff
+}
+
+// withTimeout returns ctx bounded by opts.Timeout. The returned cancel
+// function must always be called.
+func (opts ACLOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if opts.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, opts.Timeout)
+}
@@ -0,0 +1,382 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestACLOptionsTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+	clnt := newACLTestClient(t, srv)
+	opts := ACLOptions{Timeout: 200 * time.Millisecond}
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+
+	calls := map[string]func() error{
+		"GetObjectACLWithOptions": func() error {
+			_, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{ACLOptions: opts})
+			return err
+		},
+		"PutObjectACLWithOptions": func() error {
+			return clnt.PutObjectACLWithOptions(context.Background(), "bucket", "object", acle, PutObjectACLOptions{ACLOptions: opts})
+		},
+		"UpdateObjectACL": func() error {
+			return clnt.UpdateObjectACL(context.Background(), "bucket", "object", func(*AccessControlPolicyEncode) error {
+				return nil
+			}, UpdateACLOptions{ACLOptions: opts})
+		},
+	}
+	for name, call := range calls {
+		start := time.Now()
+		err := call()
+		elapsed := time.Since(start)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected a deadline exceeded error, got %v", name, err)
+		}
+		if elapsed < opts.Timeout || elapsed > opts.Timeout+time.Second {
+			t.Errorf("%s: returned after %v, expected about %v", name, elapsed, opts.Timeout)
+		}
+	}
+}
+
+func TestACLOptionsRetry(t *testing.T) {
+	var gets int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket/flaky":
+			if atomic.AddInt32(&gets, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))))
+		case "/bucket/forbidden":
+			atomic.AddInt32(&gets, 1)
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			atomic.AddInt32(&gets, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	opts := ACLOptions{MaxRetries: 3, RetryBackoff: time.Millisecond}
+
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "flaky", GetObjectACLOptions{ACLOptions: opts, SkipStat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Metadata.Get("X-Amz-Acl") != "private" {
+		t.Fatalf("unexpected metadata %v", objInfo.Metadata)
+	}
+	if gets != 3 {
+		t.Fatalf("expected 3 attempts, got %d", gets)
+	}
+
+	// Client errors are not retried.
+	atomic.StoreInt32(&gets, 0)
+	_, err = clnt.GetObjectACLWithOptions(ctx, "bucket", "forbidden", GetObjectACLOptions{ACLOptions: opts, SkipStat: true})
+	if ToErrorResponse(err).StatusCode != http.StatusForbidden || gets != 1 {
+		t.Fatalf("expected a single 403 attempt, got %d attempts and %v", gets, err)
+	}
+
+	// Retries are bounded by MaxRetries.
+	atomic.StoreInt32(&gets, 0)
+	_, err = clnt.GetObjectACLWithOptions(ctx, "bucket", "down", GetObjectACLOptions{ACLOptions: opts, SkipStat: true})
+	if ToErrorResponse(err).StatusCode != http.StatusServiceUnavailable || gets != 4 {
+		t.Fatalf("expected 4 attempts ending with 503, got %d attempts and %v", gets, err)
+	}
+
+	// A negative MaxRetries makes a single attempt.
+	atomic.StoreInt32(&gets, 0)
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	err = clnt.PutObjectACLWithOptions(ctx, "bucket", "down", acle, PutObjectACLOptions{ACLOptions: ACLOptions{MaxRetries: -1}})
+	if ToErrorResponse(err).StatusCode != http.StatusServiceUnavailable || gets != 1 {
+		t.Fatalf("expected a single 503 attempt, got %d attempts and %v", gets, err)
+	}
+}
+
+func TestACLOptionsUsePOST(t *testing.T) {
+	type request struct {
+		method, body, contentMD5 string
+		contentLength            int64
+	}
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["acl"]; !ok {
+			t.Errorf("expected an acl query, got %s", r.URL)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, string(body), r.Header.Get("Content-Md5"), r.ContentLength})
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acl := testACLXML(testUserGrantXML("owner", PermissionFullControl))
+
+	for _, usePOST := range []bool{false, true} {
+		requests = nil
+		opts := ACLOptions{UsePOST: usePOST}
+		if err := clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		if err := clnt.PutBucketACLstringWithOptions(ctx, "bucket", acl, opts); err != nil {
+			t.Fatal(err)
+		}
+		want := request{http.MethodPut, acl, sumMD5Base64([]byte(acl)), int64(len(acl))}
+		if usePOST {
+			want.method = http.MethodPost
+		}
+		if len(requests) != 2 || requests[0] != want || requests[1] != want {
+			t.Fatalf("UsePOST %v: expected two %+v requests, got %+v", usePOST, want, requests)
+		}
+	}
+}
+
+func TestACLOptionsChunked(t *testing.T) {
+	type request struct {
+		body, contentLength, transferEncoding string
+	}
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, request{string(body), r.Header.Get("Content-Length"), strings.Join(r.TransferEncoding, ",")})
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acl := testACLXML(testUserGrantXML("owner", PermissionFullControl))
+
+	for _, chunked := range []bool{false, true} {
+		requests = nil
+		opts := ACLOptions{Chunked: chunked}
+		if err := clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		if err := clnt.PutBucketACLstringWithOptions(ctx, "bucket", acl, opts); err != nil {
+			t.Fatal(err)
+		}
+		want := request{acl, strconv.Itoa(len(acl)), ""}
+		if chunked {
+			want = request{acl, "", "chunked"}
+		}
+		if len(requests) != 2 || requests[0] != want || requests[1] != want {
+			t.Fatalf("Chunked %v: expected two %+v requests, got %+v", chunked, want, requests)
+		}
+	}
+}
+
+func TestACLOptionsSchemaNamespace(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", PermissionFullControl))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+
+	testCases := []struct {
+		namespace string
+		expected  string
+	}{
+		{"", `<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`},
+		{"urn:example:acl", `<AccessControlPolicy xmlns="urn:example:acl">`},
+	}
+	for i, testCase := range testCases {
+		opts := ACLOptions{SchemaNamespace: testCase.namespace}
+		check := func(key string) {
+			t.Helper()
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+			if body := stub.acls[key]; !strings.HasPrefix(body, testCase.expected) {
+				t.Errorf("Test %d: expected %s to start with %s, got %s", i+1, key, testCase.expected, body)
+			}
+		}
+
+		if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, PutObjectACLOptions{ACLOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		check("/bucket/object?versionId=")
+		if err := clnt.UpdateObjectACL(ctx, "bucket", "object", func(acle *AccessControlPolicyEncode) error {
+			acle.AddGrant(GrantEncode{Grantee: GranteeEncode{Type: GranteeTypeGroup, URI: GroupAllUsers}, Permission: PermissionRead})
+			return nil
+		}, UpdateACLOptions{ACLOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		check("/bucket/object?versionId=")
+		if err := clnt.PutBucketACLWithOptions(ctx, "bucket", acle, PutBucketACLOptions{ACLOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		check("/bucket/?versionId=")
+	}
+	if n := stub.count(http.MethodPut); n != 6 {
+		t.Fatalf("expected 6 PUT requests, got %d", n)
+	}
+}
+
+func TestACLOptionsExtraHeaders(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.Header.Get("X-Amz-Expected-Bucket-Owner"); got != "111122223333" {
+			t.Errorf("%s: expected the expected bucket owner header, got %q", r.Method, got)
+		}
+		if got := r.Header.Get("X-Trace-Id"); got != "trace" {
+			t.Errorf("%s: expected the extra header, got %q", r.Method, got)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(testACLXML(testUserGrantXML("owner", PermissionFullControl))))
+		case http.MethodHead:
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+			w.Header().Set("Content-Length", "0")
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acl := testACLXML(testUserGrantXML("owner", PermissionFullControl))
+
+	opts := ACLOptions{
+		ExtraHeaders:        http.Header{"x-trace-id": {"trace"}},
+		ExpectedBucketOwner: "111122223333",
+	}
+	if _, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{ACLOptions: opts, SkipStat: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.GetBucketACLWithOptions(ctx, "bucket", opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.PutBucketACLstringWithOptions(ctx, "bucket", acl, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.GetObjectACLstringWithOptions(ctx, "bucket", "object", opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.GetBucketACLstringWithOptions(ctx, "bucket", opts); err != nil {
+		t.Fatal(err)
+	}
+	// The StatObject HEAD carries the headers too.
+	if _, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{ACLOptions: opts}); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 8 {
+		t.Fatalf("expected 8 requests, got %d", requests)
+	}
+
+	for _, reserved := range []string{"authorization", "X-Amz-Date", "Content-MD5"} {
+		opts := ACLOptions{ExtraHeaders: http.Header{reserved: {"forged"}}}
+		err := clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts})
+		if ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("%s: expected InvalidArgument, got %v", reserved, err)
+		}
+		if _, err = clnt.GetBucketACLWithOptions(ctx, "bucket", opts); ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("%s: expected InvalidArgument, got %v", reserved, err)
+		}
+	}
+	if requests != 8 {
+		t.Fatalf("expected no request with a reserved header, got %d requests", requests)
+	}
+
+	for _, values := range [][]string{nil, {}, {""}} {
+		opts := ACLOptions{ExtraHeaders: http.Header{"X-Foo": values}}
+		if _, err := clnt.GetBucketACLWithOptions(ctx, "bucket", opts); ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("%#v: expected InvalidArgument, got %v", values, err)
+		}
+		err := clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts})
+		if ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("%#v: expected InvalidArgument, got %v", values, err)
+		}
+	}
+	if requests != 8 {
+		t.Fatalf("expected no request with an empty header, got %d requests", requests)
+	}
+}
+
+func TestACLOptionsOnComplete(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	acl := testACLXML(testUserGrantXML("owner", PermissionFullControl))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(encodeResponse(ErrorResponse{Code: "NoSuchKey"}))
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte(acl))
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	var calls []ACLCallInfo
+	opts := ACLOptions{OnComplete: func(info ACLCallInfo) { calls = append(calls, info) }}
+	clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{ACLOptions: opts, SkipStat: true})
+	clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", acl, PutObjectACLOptions{ACLOptions: opts})
+	clnt.GetBucketACLWithOptions(ctx, "bucket", opts)
+	clnt.PutBucketACLstringWithOptions(ctx, "bucket", acl, opts)
+	if _, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "missing", GetObjectACLOptions{ACLOptions: opts}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	expected := []ACLCallInfo{
+		{Method: http.MethodGet, BucketName: "bucket", ObjectName: "object", StatusCode: http.StatusOK, Bytes: int64(len(acl))},
+		{Method: http.MethodPut, BucketName: "bucket", ObjectName: "object", StatusCode: http.StatusOK, Bytes: int64(len(acl))},
+		{Method: http.MethodGet, BucketName: "bucket", StatusCode: http.StatusOK, Bytes: int64(len(acl))},
+		{Method: http.MethodPut, BucketName: "bucket", StatusCode: http.StatusOK, Bytes: int64(len(acl))},
+		{Method: http.MethodGet, BucketName: "bucket", ObjectName: "missing", StatusCode: http.StatusNotFound},
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %d calls, got %d: %+v", len(expected), len(calls), calls)
+	}
+	for i, call := range calls {
+		if call.Duration < delay || call.Duration > 10*delay {
+			t.Errorf("call %d: unexpected duration %s", i+1, call.Duration)
+		}
+		if (call.Err != nil) != (expected[i].StatusCode == http.StatusNotFound) {
+			t.Errorf("call %d: unexpected error %v", i+1, call.Err)
+		}
+		call.Duration, call.Err = 0, nil
+		if call != expected[i] {
+			t.Errorf("call %d: expected %+v, got %+v", i+1, expected[i], call)
+		}
+	}
+}
@@ -0,0 +1,737 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Well-known group URIs used as ACL grantees.
+const (
+	GroupAllUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	GroupAuthenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+	GroupLogDelivery        = "http://acs.amazonaws.com/groups/s3/LogDelivery"
+)
+
+// GroupURIs returns the well-known group URIs.
+func GroupURIs() []string {
+	return []string{GroupAllUsers, GroupAuthenticatedUsers, GroupLogDelivery}
+}
+
+// isPublicGroup reports whether uri is one of the groups making a grant
+// public, AllUsers and AuthenticatedUsers: any AWS account can
+// authenticate.
+func isPublicGroup(uri string) bool {
+	return uri == GroupAllUsers || uri == GroupAuthenticatedUsers
+}
+
+// ACL grant permissions.
+const (
+	PermissionRead        = "READ"
+	PermissionWrite       = "WRITE"
+	PermissionReadACP     = "READ_ACP"
+	PermissionWriteACP    = "WRITE_ACP"
+	PermissionFullControl = "FULL_CONTROL"
+)
+
+// ACL grantee types, as carried by the xsi:type attribute.
+const (
+	GranteeTypeCanonicalUser = "CanonicalUser"
+	GranteeTypeEmail         = "AmazonCustomerByEmail"
+	GranteeTypeGroup         = "Group"
+)
+
+// RemoveGrant removes the grants of the canonical user granteeID with
+// permission perm. An empty perm matches any permission. It returns
+// the number of removed grants.
+func (acle *AccessControlPolicyEncode) RemoveGrant(granteeID, perm string) int {
+	key := granteeKey(GranteeEncode{Type: GranteeTypeCanonicalUser, ID: granteeID})
+	grants := acle.AccessControlList.Grants[:0]
+	removed := 0
+	for _, g := range acle.AccessControlList.Grants {
+		if granteeKey(g.Grantee) == key && (perm == "" || g.Permission == perm) {
+			removed++
+			continue
+		}
+		grants = append(grants, g)
+	}
+	acle.AccessControlList.Grants = grants
+	return removed
+}
+
+// RemoveGranteeAll removes every grant of the canonical user granteeID
+// regardless of permission. It returns the number of removed grants.
+func (acle *AccessControlPolicyEncode) RemoveGranteeAll(granteeID string) int {
+	return acle.RemoveGrant(granteeID, "")
+}
+
+// granteeKey returns the identity of a grantee, i.e. its group URI,
+// email address or canonical ID depending on its type. Email addresses
+// are case-insensitive and lowercased, canonical IDs are case-sensitive
+// and kept as is.
+func granteeKey(g GranteeEncode) string {
+	switch {
+	case g.Type == GranteeTypeGroup, g.Type == "" && g.URI != "":
+		return "uri=" + g.URI
+	case g.Type == GranteeTypeEmail, g.Type == "" && g.Email != "":
+		return "emailAddress=" + strings.ToLower(g.Email)
+	default:
+		return "id=" + g.ID
+	}
+}
+
+// AddGrant appends g unless a grant with the same grantee and
+// permission already exists. It returns true when g was added.
+func (acle *AccessControlPolicyEncode) AddGrant(g GrantEncode) bool {
+	key := granteeKey(g.Grantee)
+	for _, eg := range acle.AccessControlList.Grants {
+		if eg.Permission == g.Permission && granteeKey(eg.Grantee) == key {
+			return false
+		}
+	}
+	acle.AccessControlList.Grants = append(acle.AccessControlList.Grants, g)
+	return true
+}
+
+// ToEncode converts a decoded grant into its encodable form, setting
+// the XML schema instance attributes from the grantee type.
+func (g GrantDecode) ToEncode() GrantEncode {
+	grantee := GranteeEncode{
+		Type:        g.Grantee.Type,
+		ID:          g.Grantee.ID,
+		DisplayName: g.Grantee.DisplayName,
+		URI:         g.Grantee.URI,
+		Email:       g.Grantee.Email,
+	}
+	if grantee.Type == "" {
+		grantee.Type = g.Grantee.XMLXSI
+	}
+	if grantee.Type != "" {
+		grantee.XMLNS = xmlSchemaInstance
+		grantee.XMLXSI = grantee.Type
+	}
+	return GrantEncode{Grantee: grantee, Permission: g.Permission}
+}
+
+// policyDecodeToEncode converts a decoded policy into its encodable form.
+func policyDecodeToEncode(acld *AccessControlPolicyDecode) *AccessControlPolicyEncode {
+	acle := &AccessControlPolicyEncode{
+		Owner:      Owner{ID: acld.Owner.ID, DisplayName: acld.Owner.DisplayName},
+		Extensions: append([]ACLExtension(nil), acld.Extensions...),
+	}
+	for _, g := range acld.AccessControlList.Grants {
+		acle.AccessControlList.Grants = append(acle.AccessControlList.Grants, g.ToEncode())
+	}
+	return acle
+}
+
+// grantKey returns the identity of a grant, i.e. its grantee identity
+// and permission.
+func grantKey(g GrantEncode) string {
+	return granteeKey(g.Grantee) + " " + g.Permission
+}
+
+// ACLDiff compares the current ACL against the desired one. It returns
+// the grants present in desired but not in current, the grants present
+// in current but not in desired and whether the owner changes. Grant
+// order is not significant.
+func ACLDiff(current *AccessControlPolicyDecode, desired *AccessControlPolicyEncode) (added, removed []GrantEncode, ownerChanged bool) {
+	currentGrants := make(map[string]bool)
+	for _, g := range current.AccessControlList.Grants {
+		currentGrants[grantKey(g.ToEncode())] = true
+	}
+	desiredGrants := make(map[string]bool)
+	for _, g := range desired.AccessControlList.Grants {
+		key := grantKey(g)
+		if !currentGrants[key] && !desiredGrants[key] {
+			added = append(added, g)
+		}
+		desiredGrants[key] = true
+	}
+	for _, g := range current.AccessControlList.Grants {
+		ge := g.ToEncode()
+		key := grantKey(ge)
+		if !desiredGrants[key] {
+			removed = append(removed, ge)
+			// Report duplicated grants only once.
+			desiredGrants[key] = true
+		}
+	}
+	ownerChanged = desired.Owner.ID != "" && desired.Owner.ID != current.Owner.ID
+	return added, removed, ownerChanged
+}
+
+// Equal reports whether both policies have the same owner and the same
+// set of grants. Grant order and display names are not significant.
+func (acld *AccessControlPolicyDecode) Equal(other *AccessControlPolicyDecode) bool {
+	if acld == nil || other == nil {
+		return acld == other
+	}
+	if acld.Owner.ID != other.Owner.ID {
+		return false
+	}
+	grantSet := func(grants []GrantDecode) map[string]bool {
+		set := make(map[string]bool, len(grants))
+		for _, g := range grants {
+			set[grantKey(g.ToEncode())] = true
+		}
+		return set
+	}
+	grants, otherGrants := grantSet(acld.AccessControlList.Grants), grantSet(other.AccessControlList.Grants)
+	if len(grants) != len(otherGrants) {
+		return false
+	}
+	for key := range grants {
+		if !otherGrants[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// ACLToJSON serializes a decoded policy to JSON. The grantee type is
+// carried in the "type" field of each grantee.
+func ACLToJSON(acld *AccessControlPolicyDecode) ([]byte, error) {
+	if acld == nil {
+		return nil, errInvalidArgument("ACL policy cannot be nil.")
+	}
+	return json.Marshal(policyDecodeToEncode(acld))
+}
+
+// ACLFromJSON parses a policy serialized by ACLToJSON into an
+// encodable policy, deriving the xsi:type attributes from the grantee
+// types.
+func ACLFromJSON(data []byte) (*AccessControlPolicyEncode, error) {
+	acle := &AccessControlPolicyEncode{}
+	if err := json.Unmarshal(data, acle); err != nil {
+		return nil, err
+	}
+	for i := range acle.AccessControlList.Grants {
+		grantee := &acle.AccessControlList.Grants[i].Grantee
+		if grantee.Type != "" {
+			grantee.XMLNS = xmlSchemaInstance
+			grantee.XMLXSI = grantee.Type
+		}
+	}
+	return acle, nil
+}
+
+// validACLPermissions is the set of permissions an ACL grant may carry.
+var validACLPermissions = map[string]bool{
+	PermissionRead:        true,
+	PermissionWrite:       true,
+	PermissionReadACP:     true,
+	PermissionWriteACP:    true,
+	PermissionFullControl: true,
+}
+
+// ExpandPermissions returns perm followed by the narrower permissions
+// it implies: FULL_CONTROL implies READ, WRITE, READ_ACP and WRITE_ACP,
+// the other permissions imply nothing else. It returns nil for an
+// unknown permission.
+func ExpandPermissions(perm string) []string {
+	switch {
+	case perm == PermissionFullControl:
+		return []string{PermissionFullControl, PermissionRead, PermissionWrite, PermissionReadACP, PermissionWriteACP}
+	case validACLPermissions[perm]:
+		return []string{perm}
+	default:
+		return nil
+	}
+}
+
+// validGranteeTypes is the set of xsi:type values a grantee may carry.
+var validGranteeTypes = map[string]bool{
+	GranteeTypeCanonicalUser: true,
+	GranteeTypeEmail:         true,
+	GranteeTypeGroup:         true,
+}
+
+// MaxACLGrants is the maximum number of grants S3 accepts in an ACL.
+const MaxACLGrants = 100
+
+// GrantCount returns the number of grants of the policy.
+func (acle *AccessControlPolicyEncode) GrantCount() int {
+	return len(acle.AccessControlList.Grants)
+}
+
+// validate checks the number of grants, and the permission, grantee
+// type and grantee identity of every grant. A CanonicalUser grantee must
+// carry an ID, e.g. one added by GrantDisplayName and not resolved, a
+// Group grantee a URI and an AmazonCustomerByEmail grantee an email
+// address.
+func (acle *AccessControlPolicyEncode) validate() error {
+	if n := acle.GrantCount(); n > MaxACLGrants {
+		return errInvalidArgument(fmt.Sprintf("ACL has %d grants, at most %d are allowed.", n, MaxACLGrants))
+	}
+	for i, g := range acle.AccessControlList.Grants {
+		if !validACLPermissions[g.Permission] {
+			return errInvalidArgument(fmt.Sprintf("Invalid permission %q in grant %d.", g.Permission, i))
+		}
+		granteeType := g.Grantee.Type
+		if granteeType == "" {
+			granteeType = g.Grantee.XMLXSI
+		}
+		var identity, field string
+		switch granteeType {
+		case GranteeTypeCanonicalUser:
+			identity, field = g.Grantee.ID, "ID"
+		case GranteeTypeGroup:
+			identity, field = g.Grantee.URI, "URI"
+		case GranteeTypeEmail:
+			identity, field = g.Grantee.Email, "email address"
+		default:
+			return errInvalidArgument(fmt.Sprintf("Invalid grantee type %q in grant %d.", granteeType, i))
+		}
+		if identity == "" {
+			return errInvalidArgument(fmt.Sprintf("%s grantee of grant %d has no %s.", granteeType, i, field))
+		}
+	}
+	return nil
+}
+
+// granteeType returns the type of a grantee, inferring it from the
+// populated identity field when Type is empty.
+func granteeType(g GranteeEncode) string {
+	switch {
+	case g.Type != "":
+		return g.Type
+	case g.URI != "":
+		return GranteeTypeGroup
+	case g.Email != "":
+		return GranteeTypeEmail
+	default:
+		return GranteeTypeCanonicalUser
+	}
+}
+
+// identityType returns the grantee type matching the populated identity
+// field of a grantee, or an empty string when none is populated.
+func identityType(g GranteeDecode) string {
+	switch {
+	case g.URI != "":
+		return GranteeTypeGroup
+	case g.Email != "":
+		return GranteeTypeEmail
+	case g.ID != "":
+		return GranteeTypeCanonicalUser
+	default:
+		return ""
+	}
+}
+
+// Validate checks that the type of every grantee agrees with its
+// identity: a Group grantee carries a URI, an AmazonCustomerByEmail
+// grantee an email address and a CanonicalUser grantee an ID, and no
+// other identity field.
+func (acld *AccessControlPolicyDecode) Validate() error {
+	for i, g := range acld.AccessControlList.Grants {
+		grantee := g.Grantee
+		if !validGranteeTypes[grantee.Type] {
+			return errInvalidArgument(fmt.Sprintf("Invalid grantee type %q in grant %d.", grantee.Type, i))
+		}
+		identities := 0
+		for _, field := range []string{grantee.ID, grantee.URI, grantee.Email} {
+			if field != "" {
+				identities++
+			}
+		}
+		switch {
+		case identities == 0:
+			return errInvalidArgument(fmt.Sprintf("Grantee of grant %d has no ID, URI or email address.", i))
+		case identities > 1:
+			return errInvalidArgument(fmt.Sprintf("Grantee of grant %d has more than one of ID, URI and email address.", i))
+		case identityType(grantee) != grantee.Type:
+			return errInvalidArgument(fmt.Sprintf("Grantee type %s of grant %d does not match its %s identity.", grantee.Type, i, identityType(grantee)))
+		}
+	}
+	return nil
+}
+
+// Sanitize sets the type of every grantee, xsi:type included, to the
+// type matching its populated identity field, preferring the URI, then
+// the email address, then the ID when several are populated. Grantees
+// without identity are left untouched. It returns the number of
+// repaired grantees.
+func (acld *AccessControlPolicyDecode) Sanitize() int {
+	repaired := 0
+	for i := range acld.AccessControlList.Grants {
+		grantee := &acld.AccessControlList.Grants[i].Grantee
+		t := identityType(*grantee)
+		if t == "" || grantee.Type == t {
+			continue
+		}
+		grantee.Type, grantee.XMLXSI = t, t
+		repaired++
+	}
+	return repaired
+}
+
+// cloneExtensions returns a deep copy of exts.
+func cloneExtensions(exts []ACLExtension) []ACLExtension {
+	if exts == nil {
+		return nil
+	}
+	res := make([]ACLExtension, len(exts))
+	for i, ext := range exts {
+		res[i] = ext
+		if ext.Attrs != nil {
+			res[i].Attrs = append(make([]xml.Attr, 0, len(ext.Attrs)), ext.Attrs...)
+		}
+	}
+	return res
+}
+
+// Clone returns a deep copy of the policy which can be modified without
+// affecting acle.
+func (acle *AccessControlPolicyEncode) Clone() *AccessControlPolicyEncode {
+	if acle == nil {
+		return nil
+	}
+	res := *acle
+	if grants := acle.AccessControlList.Grants; grants != nil {
+		res.AccessControlList.Grants = append(make([]GrantEncode, 0, len(grants)), grants...)
+	}
+	res.Extensions = cloneExtensions(acle.Extensions)
+	return &res
+}
+
+// Clone returns a deep copy of the policy which can be modified without
+// affecting acld, e.g. a policy shared through an ACLCache.
+func (acld *AccessControlPolicyDecode) Clone() *AccessControlPolicyDecode {
+	if acld == nil {
+		return nil
+	}
+	res := *acld
+	if grants := acld.AccessControlList.Grants; grants != nil {
+		res.AccessControlList.Grants = append(make([]GrantDecode, 0, len(grants)), grants...)
+	}
+	res.Extensions = cloneExtensions(acld.Extensions)
+	return &res
+}
+
+// ACLSummary holds aggregate statistics of the grants of a policy.
+type ACLSummary struct {
+	// Grants is the total number of grants.
+	Grants int
+
+	// Permissions counts the grants per permission, e.g. READ.
+	Permissions map[string]int
+
+	// GranteeTypes counts the grants per grantee type, e.g. Group. A
+	// grantee without type is counted under the type matching its
+	// identity.
+	GranteeTypes map[string]int
+
+	// Public reports whether any permission is granted to the AllUsers
+	// or AuthenticatedUsers groups, the grants ListPublicObjects reports
+	// and RemovePublicAccess removes.
+	Public bool
+}
+
+// Summary returns aggregate statistics of the grants of the policy.
+func (acld *AccessControlPolicyDecode) Summary() ACLSummary {
+	summary := ACLSummary{
+		Grants:       len(acld.AccessControlList.Grants),
+		Permissions:  make(map[string]int),
+		GranteeTypes: make(map[string]int),
+	}
+	for _, g := range acld.AccessControlList.Grants {
+		summary.Permissions[g.Permission]++
+		t := g.Grantee.Type
+		if t == "" {
+			t = identityType(g.Grantee)
+		}
+		summary.GranteeTypes[t]++
+		if isPublicGroup(g.Grantee.URI) {
+			summary.Public = true
+		}
+	}
+	return summary
+}
+
+// GranteePermissions holds the permissions granted to a single grantee.
+type GranteePermissions struct {
+	Grantee     GranteeDecode
+	Permissions []string
+}
+
+// GroupedByGrantee returns the grants of the policy collapsed per
+// grantee, in order of first appearance, each with its sorted and
+// deduplicated permissions. FULL_CONTROL is listed as is, not expanded.
+func (acld *AccessControlPolicyDecode) GroupedByGrantee() []GranteePermissions {
+	var grouped []GranteePermissions
+	index := make(map[string]int)
+	for _, g := range acld.AccessControlList.Grants {
+		key := granteeKey(g.ToEncode().Grantee)
+		i, ok := index[key]
+		if !ok {
+			i = len(grouped)
+			index[key] = i
+			grouped = append(grouped, GranteePermissions{Grantee: g.Grantee})
+		}
+		perms := grouped[i].Permissions
+		j := sort.SearchStrings(perms, g.Permission)
+		if j < len(perms) && perms[j] == g.Permission {
+			continue
+		}
+		perms = append(perms, "")
+		copy(perms[j+1:], perms[j:])
+		perms[j] = g.Permission
+		grouped[i].Permissions = perms
+	}
+	return grouped
+}
+
+// GranteeDisplayMap returns the display names of the canonical users
+// of the policy, the owner and the grantees, keyed by canonical ID.
+// An ID without display name maps to an empty string; a name found
+// anywhere in the policy wins over a missing one.
+func (acld *AccessControlPolicyDecode) GranteeDisplayMap() map[string]string {
+	names := make(map[string]string)
+	add := func(id, name string) {
+		if id == "" {
+			return
+		}
+		if names[id] == "" {
+			names[id] = name
+		}
+	}
+	add(acld.Owner.ID, acld.Owner.DisplayName)
+	for _, g := range acld.AccessControlList.Grants {
+		add(g.Grantee.ID, g.Grantee.DisplayName)
+	}
+	return names
+}
+
+// Normalize removes duplicated grants and sorts the grants by grantee
+// type, grantee identity and permission so that the marshaled policy
+// is deterministic. When coalesceFullControl is set, a grantee holding
+// READ, WRITE, READ_ACP and WRITE_ACP, or FULL_CONTROL, is left with a
+// single FULL_CONTROL grant.
+func (acle *AccessControlPolicyEncode) Normalize(coalesceFullControl bool) {
+	var grants []GrantEncode
+	seen := make(map[string]bool)
+	perms := make(map[string]map[string]bool)
+	for _, g := range acle.AccessControlList.Grants {
+		key := grantKey(g)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		grants = append(grants, g)
+
+		identity := granteeKey(g.Grantee)
+		if perms[identity] == nil {
+			perms[identity] = make(map[string]bool)
+		}
+		perms[identity][g.Permission] = true
+	}
+
+	if coalesceFullControl {
+		fullControl := func(p map[string]bool) bool {
+			return p[PermissionFullControl] ||
+				p[PermissionRead] && p[PermissionWrite] && p[PermissionReadACP] && p[PermissionWriteACP]
+		}
+		coalesced := grants[:0]
+		for _, g := range grants {
+			identity := granteeKey(g.Grantee)
+			if fullControl(perms[identity]) {
+				if g.Permission != PermissionFullControl && perms[identity][PermissionFullControl] {
+					continue
+				}
+				if g.Permission != PermissionFullControl {
+					// First narrower grant stands for the whole set.
+					g.Permission = PermissionFullControl
+					perms[identity][PermissionFullControl] = true
+				}
+			}
+			coalesced = append(coalesced, g)
+		}
+		grants = coalesced
+	}
+
+	sort.SliceStable(grants, func(i, j int) bool {
+		ti, tj := granteeType(grants[i].Grantee), granteeType(grants[j].Grantee)
+		if ti != tj {
+			return ti < tj
+		}
+		ki, kj := granteeKey(grants[i].Grantee), granteeKey(grants[j].Grantee)
+		if ki != kj {
+			return ki < kj
+		}
+		return grants[i].Permission < grants[j].Permission
+	})
+	acle.AccessControlList.Grants = grants
+}
+
+// ToDecode converts an encodable grant into its decoded form, as if it
+// had been read back from the server.
+func (g GrantEncode) ToDecode() GrantDecode {
+	grantee := GranteeDecode{
+		Type:        g.Grantee.Type,
+		ID:          g.Grantee.ID,
+		DisplayName: g.Grantee.DisplayName,
+		URI:         g.Grantee.URI,
+		Email:       g.Grantee.Email,
+	}
+	if grantee.Type == "" {
+		grantee.Type = g.Grantee.XMLXSI
+	}
+	if grantee.Type != "" {
+		grantee.XMLNS = xmlSchemaInstance
+		grantee.XMLXSI = grantee.Type
+	}
+	return GrantDecode{Grantee: grantee, Permission: g.Permission}
+}
+
+// policyEncodeToDecode converts an encodable policy into its decoded form.
+func policyEncodeToDecode(acle *AccessControlPolicyEncode) *AccessControlPolicyDecode {
+	acld := &AccessControlPolicyDecode{
+		Owner:      Owner{ID: acle.Owner.ID, DisplayName: acle.Owner.DisplayName},
+		Extensions: append([]ACLExtension(nil), acle.Extensions...),
+	}
+	for _, g := range acle.AccessControlList.Grants {
+		acld.AccessControlList.Grants = append(acld.AccessControlList.Grants, g.ToDecode())
+	}
+	return acld
+}
+
+// ExpandCannedACL returns the grants S3 materializes for the canned ACL
+// canned on a resource owned by owner. bucketOwner is only required by
+// bucket-owner-read and bucket-owner-full-control.
+func ExpandCannedACL(canned string, owner Owner, bucketOwner *Owner) (*AccessControlPolicyEncode, error) {
+	b := NewACLBuilder(owner).GrantCanonicalUser(owner.ID, PermissionFullControl)
+	switch canned {
+	case "private":
+	case "public-read":
+		b.GrantGroup(GroupAllUsers, PermissionRead)
+	case "public-read-write":
+		b.GrantGroup(GroupAllUsers, PermissionRead).GrantGroup(GroupAllUsers, PermissionWrite)
+	case "authenticated-read":
+		b.GrantGroup(GroupAuthenticatedUsers, PermissionRead)
+	case "log-delivery-write":
+		b.GrantGroup(GroupLogDelivery, PermissionWrite).GrantGroup(GroupLogDelivery, PermissionReadACP)
+	case "bucket-owner-read", "bucket-owner-full-control":
+		if bucketOwner == nil || bucketOwner.ID == "" {
+			return nil, errInvalidArgument("Bucket owner is required to expand canned ACL " + canned + ".")
+		}
+		perm := PermissionRead
+		if canned == "bucket-owner-full-control" {
+			perm = PermissionFullControl
+		}
+		// S3 does not repeat the grant when both owners are the same.
+		if bucketOwner.ID != owner.ID {
+			b.GrantCanonicalUser(bucketOwner.ID, perm)
+		}
+	default:
+		return nil, errInvalidArgument("Unsupported canned ACL " + canned + ".")
+	}
+	return b.Build(), nil
+}
+
+// CannedACLName returns the canned ACL whose expansion matches the
+// policy grants, or an empty string. bucketOwnerID may be empty when the
+// bucket owner is unknown, in which case the bucket-owner-* canned ACLs
+// are not detected.
+func CannedACLName(acle *AccessControlPolicyEncode, bucketOwnerID string) string {
+	return getCannedACL(policyEncodeToDecode(acle), bucketOwnerID)
+}
+
+// MergeACL returns the union of the grants of the given policies,
+// grants held by the same grantee with the same permission being kept
+// once. The owner is taken from the first policy with a non-empty
+// owner; see MergeACLStrict to reject policies with different owners.
+// Nil policies are ignored.
+func MergeACL(policies ...*AccessControlPolicyEncode) *AccessControlPolicyEncode {
+	merged := &AccessControlPolicyEncode{}
+	for _, acle := range policies {
+		if acle == nil {
+			continue
+		}
+		if merged.Owner == (Owner{}) {
+			merged.Owner = acle.Owner
+		}
+		for _, g := range acle.AccessControlList.Grants {
+			merged.AddGrant(g)
+		}
+	}
+	return merged
+}
+
+// MergeACLStrict is like MergeACL but fails when two policies have
+// owners with different IDs.
+func MergeACLStrict(policies ...*AccessControlPolicyEncode) (*AccessControlPolicyEncode, error) {
+	var ownerID string
+	for _, acle := range policies {
+		if acle == nil || acle.Owner.ID == "" {
+			continue
+		}
+		if ownerID != "" && acle.Owner.ID != ownerID {
+			return nil, errInvalidArgument(fmt.Sprintf("Conflicting ACL owners %q and %q.", ownerID, acle.Owner.ID))
+		}
+		ownerID = acle.Owner.ID
+	}
+	return MergeACL(policies...), nil
+}
+
+// HasPermission reports whether the canonical user granteeID is granted
+// permission by the policy. An empty granteeID stands for an anonymous
+// principal, any other ID for an authenticated one; see
+// HasPermissionForPrincipal.
+func HasPermission(acld *AccessControlPolicyDecode, granteeID, permission string) bool {
+	return HasPermissionForPrincipal(acld, granteeID, permission, granteeID != "")
+}
+
+// HasPermissionForPrincipal reports whether the principal with the
+// canonical ID granteeID, which may be empty, is granted permission by
+// the policy. FULL_CONTROL implies every permission, AllUsers grants
+// apply to any principal and AuthenticatedUsers grants apply when
+// authenticated is set. As in S3, the owner of the resource always
+// holds READ_ACP and WRITE_ACP.
+func HasPermissionForPrincipal(acld *AccessControlPolicyDecode, granteeID, permission string, authenticated bool) bool {
+	if acld == nil || !validACLPermissions[permission] {
+		return false
+	}
+	if granteeID != "" && granteeID == acld.Owner.ID &&
+		(permission == PermissionReadACP || permission == PermissionWriteACP) {
+		return true
+	}
+	for _, g := range acld.AccessControlList.Grants {
+		if g.Permission != permission && g.Permission != PermissionFullControl {
+			continue
+		}
+		switch {
+		case g.Grantee.URI == GroupAllUsers:
+			return true
+		case g.Grantee.URI == GroupAuthenticatedUsers:
+			if authenticated {
+				return true
+			}
+		case g.Grantee.URI != "":
+			// Other groups, e.g. LogDelivery, are not canonical users.
+		case granteeID != "" && g.Grantee.ID == granteeID:
+			return true
+		}
+	}
+	return false
+}
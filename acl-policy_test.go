@@ -0,0 +1,948 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveGrant(t *testing.T) {
+	newPolicy := func() *AccessControlPolicyEncode {
+		return NewACLBuilder(Owner{ID: "owner"}).
+			GrantCanonicalUser("a", "READ").
+			GrantCanonicalUser("b", "READ").
+			GrantCanonicalUser("a", "WRITE").
+			GrantCanonicalUser("c", "READ").
+			Build()
+	}
+
+	testCases := []struct {
+		granteeID string
+		perm      string
+		removed   int
+		remaining []string
+	}{
+		// Test 1: remove from the start.
+		{"a", "READ", 1, []string{"b:READ", "a:WRITE", "c:READ"}},
+		// Test 2: remove from the middle.
+		{"b", "READ", 1, []string{"a:READ", "a:WRITE", "c:READ"}},
+		// Test 3: remove from the end.
+		{"c", "READ", 1, []string{"a:READ", "b:READ", "a:WRITE"}},
+		// Test 4: empty permission matches any permission.
+		{"a", "", 2, []string{"b:READ", "c:READ"}},
+		// Test 5: no match.
+		{"b", "WRITE", 0, []string{"a:READ", "b:READ", "a:WRITE", "c:READ"}},
+	}
+
+	for i, testCase := range testCases {
+		policy := newPolicy()
+		if removed := policy.RemoveGrant(testCase.granteeID, testCase.perm); removed != testCase.removed {
+			t.Errorf("Test %d: expected %d removed grants, got %d", i+1, testCase.removed, removed)
+		}
+		var remaining []string
+		for _, g := range policy.AccessControlList.Grants {
+			remaining = append(remaining, g.Grantee.ID+":"+g.Permission)
+		}
+		if strings.Join(remaining, ",") != strings.Join(testCase.remaining, ",") {
+			t.Errorf("Test %d: expected grants %v, got %v", i+1, testCase.remaining, remaining)
+		}
+	}
+}
+
+func TestRemoveGranteeAll(t *testing.T) {
+	policy := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("a", "READ").
+		GrantCanonicalUser("b", "READ").
+		GrantCanonicalUser("a", "FULL_CONTROL").
+		Build()
+	if removed := policy.RemoveGranteeAll("a"); removed != 2 {
+		t.Fatalf("expected 2 removed grants, got %d", removed)
+	}
+	if len(policy.AccessControlList.Grants) != 1 || policy.AccessControlList.Grants[0].Grantee.ID != "b" {
+		t.Fatalf("unexpected remaining grants %+v", policy.AccessControlList.Grants)
+	}
+}
+
+func TestAddGrant(t *testing.T) {
+	policy := NewACLBuilder(Owner{ID: "owner"}).Build()
+	grants := NewACLBuilder(Owner{}).
+		GrantCanonicalUser("abc123", "READ").
+		GrantEmail("user@example.com", "READ").
+		GrantGroup("http://acs.amazonaws.com/groups/global/AllUsers", "READ").
+		GrantCanonicalUser("abc123", "WRITE").
+		Build().AccessControlList.Grants
+
+	for round := 0; round < 3; round++ {
+		for i, g := range grants {
+			added := policy.AddGrant(g)
+			if added != (round == 0) {
+				t.Errorf("round %d, grant %d: expected added=%t, got %t", round, i+1, round == 0, added)
+			}
+		}
+		if n := len(policy.AccessControlList.Grants); n != len(grants) {
+			t.Fatalf("round %d: expected %d grants, got %d", round, len(grants), n)
+		}
+	}
+
+	// A grantee of another type sharing the same value is distinct.
+	g := GrantEncode{Grantee: GranteeEncode{Type: "Group", URI: "abc123"}, Permission: "READ"}
+	if !policy.AddGrant(g) {
+		t.Fatal("expected group grant to be added")
+	}
+}
+
+func TestGranteeKeyCase(t *testing.T) {
+	policy := NewACLBuilder(Owner{ID: "owner"}).
+		GrantEmail("Bob@EXAMPLE.com", PermissionRead).
+		GrantCanonicalUser("AbC123", PermissionRead).Build()
+
+	// Email addresses are case-insensitive.
+	if policy.AddGrant(NewACLBuilder(Owner{}).GrantEmail("bob@example.com", PermissionRead).Build().AccessControlList.Grants[0]) {
+		t.Fatal("expected the email grant to be a duplicate")
+	}
+	// Canonical IDs are case-sensitive.
+	if !policy.AddGrant(NewACLBuilder(Owner{}).GrantCanonicalUser("abc123", PermissionRead).Build().AccessControlList.Grants[0]) {
+		t.Fatal("expected the canonical user grant to be added")
+	}
+	if n := policy.RemoveGrant("ABC123", ""); n != 0 {
+		t.Fatalf("expected no grant removed, got %d", n)
+	}
+
+	lower := NewACLBuilder(Owner{ID: "owner"}).
+		GrantEmail("bob@example.com", PermissionRead).
+		GrantCanonicalUser("AbC123", PermissionRead).
+		GrantCanonicalUser("abc123", PermissionRead).Build()
+	if !policyEncodeToDecode(policy).Equal(policyEncodeToDecode(lower)) {
+		t.Fatal("expected the policies to be equal")
+	}
+	if added, removed, _ := ACLDiff(policyEncodeToDecode(policy), lower); len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("expected no difference, got added %v and removed %v", added, removed)
+	}
+
+	upper := NewACLBuilder(Owner{ID: "owner"}).
+		GrantEmail("BOB@example.com", PermissionRead).
+		GrantCanonicalUser("ABC123", PermissionRead).
+		GrantCanonicalUser("abc123", PermissionRead).Build()
+	added, removed, _ := ACLDiff(policyEncodeToDecode(policy), upper)
+	if len(added) != 1 || added[0].Grantee.ID != "ABC123" || len(removed) != 1 || removed[0].Grantee.ID != "AbC123" {
+		t.Fatalf("expected only the canonical ID to differ, got added %v and removed %v", added, removed)
+	}
+	if policyEncodeToDecode(policy).Equal(policyEncodeToDecode(upper)) {
+		t.Fatal("expected the policies to differ")
+	}
+}
+
+func TestACLDiff(t *testing.T) {
+	allUsers := "http://acs.amazonaws.com/groups/global/AllUsers"
+	decoded := func(owner string, grants ...GrantEncode) *AccessControlPolicyDecode {
+		acld := &AccessControlPolicyDecode{Owner: Owner{ID: owner}}
+		for _, g := range grants {
+			acld.AccessControlList.Grants = append(acld.AccessControlList.Grants, GrantDecode{
+				Grantee: GranteeDecode{
+					Type:  g.Grantee.Type,
+					ID:    g.Grantee.ID,
+					URI:   g.Grantee.URI,
+					Email: g.Grantee.Email,
+				},
+				Permission: g.Permission,
+			})
+		}
+		return acld
+	}
+	grants := NewACLBuilder(Owner{}).
+		GrantCanonicalUser("owner", "FULL_CONTROL").
+		GrantGroup(allUsers, "READ").
+		GrantEmail("user@example.com", "WRITE").
+		GrantCanonicalUser("abc123", "READ").
+		Build().AccessControlList.Grants
+	ownerFull, publicRead, emailWrite, userRead := grants[0], grants[1], grants[2], grants[3]
+
+	testCases := []struct {
+		current      *AccessControlPolicyDecode
+		desired      *AccessControlPolicyEncode
+		added        []string
+		removed      []string
+		ownerChanged bool
+	}{
+		// Test 1: identical.
+		{
+			decoded("owner", ownerFull, publicRead),
+			&AccessControlPolicyEncode{Owner: Owner{ID: "owner"}, AccessControlList: AccessControlListEncode{Grants: []GrantEncode{ownerFull, publicRead}}},
+			nil, nil, false,
+		},
+		// Test 2: reordered but equal.
+		{
+			decoded("owner", ownerFull, publicRead, emailWrite),
+			&AccessControlPolicyEncode{Owner: Owner{ID: "owner"}, AccessControlList: AccessControlListEncode{Grants: []GrantEncode{emailWrite, publicRead, ownerFull}}},
+			nil, nil, false,
+		},
+		// Test 3: grant added.
+		{
+			decoded("owner", ownerFull),
+			&AccessControlPolicyEncode{Owner: Owner{ID: "owner"}, AccessControlList: AccessControlListEncode{Grants: []GrantEncode{ownerFull, userRead}}},
+			[]string{grantKey(userRead)}, nil, false,
+		},
+		// Test 4: grant removed.
+		{
+			decoded("owner", ownerFull, publicRead),
+			&AccessControlPolicyEncode{Owner: Owner{ID: "owner"}, AccessControlList: AccessControlListEncode{Grants: []GrantEncode{ownerFull}}},
+			nil, []string{grantKey(publicRead)}, false,
+		},
+		// Test 5: grant replaced and owner changed.
+		{
+			decoded("owner", ownerFull, publicRead),
+			&AccessControlPolicyEncode{Owner: Owner{ID: "new-owner"}, AccessControlList: AccessControlListEncode{Grants: []GrantEncode{ownerFull, emailWrite}}},
+			[]string{grantKey(emailWrite)}, []string{grantKey(publicRead)}, true,
+		},
+		// Test 6: duplicated grants diff as a single entry.
+		{
+			decoded("owner", publicRead, publicRead),
+			&AccessControlPolicyEncode{AccessControlList: AccessControlListEncode{Grants: []GrantEncode{userRead, userRead}}},
+			[]string{grantKey(userRead)}, []string{grantKey(publicRead)}, false,
+		},
+	}
+
+	keys := func(grants []GrantEncode) []string {
+		var res []string
+		for _, g := range grants {
+			res = append(res, grantKey(g))
+		}
+		return res
+	}
+
+	for i, testCase := range testCases {
+		added, removed, ownerChanged := ACLDiff(testCase.current, testCase.desired)
+		if !reflect.DeepEqual(keys(added), testCase.added) {
+			t.Errorf("Test %d: expected added %v, got %v", i+1, testCase.added, keys(added))
+		}
+		if !reflect.DeepEqual(keys(removed), testCase.removed) {
+			t.Errorf("Test %d: expected removed %v, got %v", i+1, testCase.removed, keys(removed))
+		}
+		if ownerChanged != testCase.ownerChanged {
+			t.Errorf("Test %d: expected ownerChanged %t, got %t", i+1, testCase.ownerChanged, ownerChanged)
+		}
+	}
+}
+
+func TestAccessControlPolicyDecodeEqual(t *testing.T) {
+	owner := GrantDecode{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "owner", DisplayName: "Owner"}, Permission: "FULL_CONTROL"}
+	ownerNoName := GrantDecode{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "owner"}, Permission: "FULL_CONTROL"}
+	public := GrantDecode{Grantee: GranteeDecode{Type: "Group", URI: "http://acs.amazonaws.com/groups/global/AllUsers"}, Permission: "READ"}
+	email := GrantDecode{Grantee: GranteeDecode{Type: "AmazonCustomerByEmail", Email: "user@example.com"}, Permission: "WRITE"}
+
+	policy := func(owner Owner, grants ...GrantDecode) *AccessControlPolicyDecode {
+		return &AccessControlPolicyDecode{Owner: owner, AccessControlList: AccessControlListDecode{Grants: grants}}
+	}
+
+	testCases := []struct {
+		a, b  *AccessControlPolicyDecode
+		equal bool
+	}{
+		// Test 1: shuffled grant order.
+		{policy(Owner{ID: "owner"}, owner, public, email), policy(Owner{ID: "owner"}, email, owner, public), true},
+		// Test 2: missing display names.
+		{policy(Owner{ID: "owner", DisplayName: "Owner"}, owner, public), policy(Owner{ID: "owner"}, public, ownerNoName), true},
+		// Test 3: different owner.
+		{policy(Owner{ID: "owner"}, owner), policy(Owner{ID: "other"}, owner), false},
+		// Test 4: missing grant.
+		{policy(Owner{ID: "owner"}, owner, public), policy(Owner{ID: "owner"}, owner), false},
+		// Test 5: different permission.
+		{policy(Owner{ID: "owner"}, public), policy(Owner{ID: "owner"}, GrantDecode{Grantee: public.Grantee, Permission: "WRITE"}), false},
+		// Test 6: nil policies.
+		{nil, nil, true},
+		{policy(Owner{ID: "owner"}), nil, false},
+	}
+
+	for i, testCase := range testCases {
+		if got := testCase.a.Equal(testCase.b); got != testCase.equal {
+			t.Errorf("Test %d: expected %t, got %t", i+1, testCase.equal, got)
+		}
+		if got := testCase.b.Equal(testCase.a); got != testCase.equal {
+			t.Errorf("Test %d (reversed): expected %t, got %t", i+1, testCase.equal, got)
+		}
+	}
+}
+
+func TestACLJSONRoundTrip(t *testing.T) {
+	acld := &AccessControlPolicyDecode{}
+	if err := xmlDecoder(strings.NewReader(testLogDeliveryACLXML), acld); err != nil {
+		t.Fatal(err)
+	}
+	for i := range acld.AccessControlList.Grants {
+		acld.AccessControlList.Grants[i].Grantee.Type = acld.AccessControlList.Grants[i].Grantee.XMLXSI
+	}
+
+	data, err := ACLToJSON(acld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ACLToJSON(acld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatalf("JSON output is not stable: %s != %s", data, again)
+	}
+
+	acle, err := ACLFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := xml.Marshal(policyDecodeToEncode(acld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := xml.Marshal(acle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Fatalf("expected XML %s, got %s", want, got)
+	}
+	if !bytes.Contains(got, []byte(`xsi:type="Group"`)) {
+		t.Fatalf("xsi:type attributes were not derived: %s", got)
+	}
+}
+
+func TestACLFromJSONInvalid(t *testing.T) {
+	if _, err := ACLFromJSON([]byte("{")); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+func TestGroupURIs(t *testing.T) {
+	want := []string{
+		"http://acs.amazonaws.com/groups/global/AllUsers",
+		"http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+		"http://acs.amazonaws.com/groups/s3/LogDelivery",
+	}
+	if got := GroupURIs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	grants := NewACLBuilder(Owner{}).
+		GrantCanonicalUser("b", "READ").
+		GrantGroup(GroupAllUsers, "READ").
+		GrantCanonicalUser("a", "WRITE").
+		GrantEmail("user@example.com", "READ").
+		GrantCanonicalUser("a", "READ").
+		GrantCanonicalUser("b", "READ").
+		Build().AccessControlList.Grants
+
+	var want []byte
+	for i := 0; i < 20; i++ {
+		shuffled := append([]GrantEncode(nil), grants...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		acle := &AccessControlPolicyEncode{Owner: Owner{ID: "owner"}, AccessControlList: AccessControlListEncode{Grants: shuffled}}
+		acle.Normalize(false)
+
+		got, err := xml.Marshal(acle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = got
+		} else if !bytes.Equal(want, got) {
+			t.Fatalf("non deterministic output:\n%s\n%s", want, got)
+		}
+	}
+
+	acle := &AccessControlPolicyEncode{AccessControlList: AccessControlListEncode{Grants: grants}}
+	acle.Normalize(false)
+	var keys []string
+	for _, g := range acle.AccessControlList.Grants {
+		keys = append(keys, grantKey(g))
+	}
+	wantKeys := []string{
+		"emailAddress=user@example.com READ",
+		"id=a READ",
+		"id=a WRITE",
+		"id=b READ",
+		"uri=" + GroupAllUsers + " READ",
+	}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("expected %v, got %v", wantKeys, keys)
+	}
+}
+
+func TestNormalizeCoalesceFullControl(t *testing.T) {
+	testCases := []struct {
+		grants []GrantEncode
+		want   []string
+	}{
+		// Test 1: all four narrower permissions collapse.
+		{
+			NewACLBuilder(Owner{}).
+				GrantCanonicalUser("a", "READ").GrantCanonicalUser("a", "WRITE").
+				GrantCanonicalUser("a", "READ_ACP").GrantCanonicalUser("a", "WRITE_ACP").
+				GrantCanonicalUser("b", "READ").
+				Build().AccessControlList.Grants,
+			[]string{"id=a FULL_CONTROL", "id=b READ"},
+		},
+		// Test 2: FULL_CONTROL absorbs narrower permissions.
+		{
+			NewACLBuilder(Owner{}).
+				GrantCanonicalUser("a", "READ").GrantCanonicalUser("a", "FULL_CONTROL").
+				Build().AccessControlList.Grants,
+			[]string{"id=a FULL_CONTROL"},
+		},
+		// Test 3: an incomplete set is kept.
+		{
+			NewACLBuilder(Owner{}).
+				GrantCanonicalUser("a", "READ").GrantCanonicalUser("a", "WRITE").
+				Build().AccessControlList.Grants,
+			[]string{"id=a READ", "id=a WRITE"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		acle := &AccessControlPolicyEncode{AccessControlList: AccessControlListEncode{Grants: testCase.grants}}
+		acle.Normalize(true)
+		var keys []string
+		for _, g := range acle.AccessControlList.Grants {
+			keys = append(keys, grantKey(g))
+		}
+		if !reflect.DeepEqual(keys, testCase.want) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.want, keys)
+		}
+	}
+}
+
+func TestExpandCannedACL(t *testing.T) {
+	owner := Owner{ID: "owner"}
+	bucketOwner := &Owner{ID: "bucket-owner"}
+	ownerFull := "id=owner FULL_CONTROL"
+
+	testCases := []struct {
+		canned      string
+		bucketOwner *Owner
+		grants      []string
+		reverse     bool
+	}{
+		{"private", nil, []string{ownerFull}, true},
+		{"public-read", nil, []string{ownerFull, "uri=" + GroupAllUsers + " READ"}, true},
+		{"public-read-write", nil, []string{ownerFull, "uri=" + GroupAllUsers + " READ", "uri=" + GroupAllUsers + " WRITE"}, true},
+		{"authenticated-read", nil, []string{ownerFull, "uri=" + GroupAuthenticatedUsers + " READ"}, true},
+		{"log-delivery-write", nil, []string{ownerFull, "uri=" + GroupLogDelivery + " WRITE", "uri=" + GroupLogDelivery + " READ_ACP"}, true},
+		{"bucket-owner-read", bucketOwner, []string{ownerFull, "id=bucket-owner READ"}, true},
+		{"bucket-owner-full-control", bucketOwner, []string{ownerFull, "id=bucket-owner FULL_CONTROL"}, true},
+		{"bucket-owner-full-control", &owner, []string{ownerFull}, false},
+	}
+
+	for i, testCase := range testCases {
+		acle, err := ExpandCannedACL(testCase.canned, owner, testCase.bucketOwner)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		var keys []string
+		for _, g := range acle.AccessControlList.Grants {
+			keys = append(keys, grantKey(g))
+			if err := acle.validate(); err != nil {
+				t.Errorf("Test %d: %v", i+1, err)
+			}
+		}
+		if !reflect.DeepEqual(keys, testCase.grants) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.grants, keys)
+		}
+		if acle.Owner != owner {
+			t.Errorf("Test %d: unexpected owner %+v", i+1, acle.Owner)
+		}
+		if !testCase.reverse {
+			continue
+		}
+		bucketOwnerID := ""
+		if testCase.bucketOwner != nil {
+			bucketOwnerID = testCase.bucketOwner.ID
+		}
+		if got := CannedACLName(acle, bucketOwnerID); got != testCase.canned {
+			t.Errorf("Test %d: expected reverse %q, got %q", i+1, testCase.canned, got)
+		}
+	}
+}
+
+func TestExpandCannedACLErrors(t *testing.T) {
+	owner := Owner{ID: "owner"}
+	for i, testCase := range []struct {
+		canned      string
+		bucketOwner *Owner
+	}{
+		{"bucket-owner-read", nil},
+		{"bucket-owner-full-control", &Owner{}},
+		{"aws-exec-read", nil},
+		{"", nil},
+	} {
+		if _, err := ExpandCannedACL(testCase.canned, owner, testCase.bucketOwner); ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("Test %d: expected InvalidArgument, got %v", i+1, err)
+		}
+	}
+}
+
+func TestMergeACL(t *testing.T) {
+	owner := Owner{ID: "owner", DisplayName: "owner"}
+	a := NewACLBuilder(owner).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	b := NewACLBuilder(Owner{}).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		GrantEmail("user@example.com", PermissionWrite).
+		Build()
+
+	merged := MergeACL(nil, b, a)
+	if merged.Owner != owner {
+		t.Fatalf("expected owner %+v, got %+v", owner, merged.Owner)
+	}
+	var keys []string
+	for _, g := range merged.AccessControlList.Grants {
+		keys = append(keys, grantKey(g))
+	}
+	want := []string{
+		"uri=" + GroupAllUsers + " READ",
+		"emailAddress=user@example.com WRITE",
+		"id=owner FULL_CONTROL",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected grants %v, got %v", want, keys)
+	}
+	if len(a.AccessControlList.Grants) != 2 || len(b.AccessControlList.Grants) != 2 {
+		t.Fatal("merging modified the input policies")
+	}
+
+	if merged := MergeACL(); merged == nil || len(merged.AccessControlList.Grants) != 0 {
+		t.Fatalf("unexpected empty merge %+v", merged)
+	}
+}
+
+func TestMergeACLStrict(t *testing.T) {
+	a := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	b := NewACLBuilder(Owner{}).GrantGroup(GroupAllUsers, PermissionRead).Build()
+	c := NewACLBuilder(Owner{ID: "other"}).GrantCanonicalUser("other", PermissionFullControl).Build()
+
+	merged, err := MergeACLStrict(a, b, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Owner.ID != "owner" || len(merged.AccessControlList.Grants) != 2 {
+		t.Fatalf("unexpected merge %+v", merged)
+	}
+
+	if _, err = MergeACLStrict(a, b, c); ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument for conflicting owners, got %v", err)
+	}
+}
+
+func TestGrantConversion(t *testing.T) {
+	testCases := []struct {
+		decoded GrantDecode
+		encoded GrantEncode
+	}{
+		{
+			GrantDecode{
+				Grantee:    GranteeDecode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeCanonicalUser, Type: GranteeTypeCanonicalUser, ID: "id", DisplayName: "name"},
+				Permission: PermissionFullControl,
+			},
+			GrantEncode{
+				Grantee:    GranteeEncode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeCanonicalUser, Type: GranteeTypeCanonicalUser, ID: "id", DisplayName: "name"},
+				Permission: PermissionFullControl,
+			},
+		},
+		{
+			GrantDecode{
+				Grantee:    GranteeDecode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeEmail, Type: GranteeTypeEmail, Email: "user@example.com"},
+				Permission: PermissionWriteACP,
+			},
+			GrantEncode{
+				Grantee:    GranteeEncode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeEmail, Type: GranteeTypeEmail, Email: "user@example.com"},
+				Permission: PermissionWriteACP,
+			},
+		},
+		{
+			GrantDecode{
+				Grantee:    GranteeDecode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeGroup, Type: GranteeTypeGroup, URI: GroupAllUsers},
+				Permission: PermissionRead,
+			},
+			GrantEncode{
+				Grantee:    GranteeEncode{XMLNS: xmlSchemaInstance, XMLXSI: GranteeTypeGroup, Type: GranteeTypeGroup, URI: GroupAllUsers},
+				Permission: PermissionRead,
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		if got := testCase.decoded.ToEncode(); !reflect.DeepEqual(got, testCase.encoded) {
+			t.Errorf("Test %d: ToEncode expected %+v, got %+v", i+1, testCase.encoded, got)
+		}
+		if got := testCase.encoded.ToDecode(); !reflect.DeepEqual(got, testCase.decoded) {
+			t.Errorf("Test %d: ToDecode expected %+v, got %+v", i+1, testCase.decoded, got)
+		}
+
+		// The encoded grant marshals with the xsi attributes and decodes
+		// back to the same grant.
+		buf, err := xml.Marshal(testCase.encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `<Grantee xmlns:xsi="` + xmlSchemaInstance + `" xsi:type="` + testCase.encoded.Grantee.Type + `">`
+		if !strings.Contains(string(buf), want) {
+			t.Errorf("Test %d: %s does not contain %s", i+1, buf, want)
+		}
+		acld := &AccessControlPolicyDecode{}
+		doc := testACLXML(string(buf))
+		if err = xmlDecoder(strings.NewReader(doc), acld); err != nil {
+			t.Fatal(err)
+		}
+		got := acld.AccessControlList.Grants[0]
+		got.Grantee.Type = got.Grantee.XMLXSI
+		if got.ToEncode().Grantee != testCase.encoded.Grantee {
+			t.Errorf("Test %d: round trip expected %+v, got %+v", i+1, testCase.encoded.Grantee, got.ToEncode().Grantee)
+		}
+	}
+
+	// The type is taken from the xsi:type attribute when Type is unset.
+	g := GrantDecode{Grantee: GranteeDecode{XMLXSI: GranteeTypeGroup, URI: GroupLogDelivery}, Permission: PermissionWrite}
+	if ge := g.ToEncode(); ge.Grantee.Type != GranteeTypeGroup || ge.Grantee.XMLNS != xmlSchemaInstance {
+		t.Errorf("unexpected conversion of an untyped grant %+v", ge)
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	decode := func(grants ...string) *AccessControlPolicyDecode {
+		acld := &AccessControlPolicyDecode{}
+		if err := xmlDecoder(strings.NewReader(testACLXML(grants...)), acld); err != nil {
+			t.Fatal(err)
+		}
+		return acld
+	}
+	private := decode(
+		testUserGrantXML("reader", "READ"),
+		testUserGrantXML("writer", "WRITE"),
+		testUserGrantXML("writer", "READ_ACP"),
+		testUserGrantXML("admin", "FULL_CONTROL"),
+		testGroupGrantXML(GroupAuthenticatedUsers, "READ_ACP"),
+		testGroupGrantXML(GroupLogDelivery, "WRITE"),
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="AmazonCustomerByEmail"><EmailAddress>user@example.com</EmailAddress></Grantee><Permission>WRITE_ACP</Permission></Grant>`,
+	)
+	public := decode(testGroupGrantXML(GroupAllUsers, "READ"))
+	publicFull := decode(testGroupGrantXML(GroupAllUsers, "FULL_CONTROL"))
+	authenticatedFull := decode(testGroupGrantXML(GroupAuthenticatedUsers, "FULL_CONTROL"))
+
+	testCases := []struct {
+		policy        *AccessControlPolicyDecode
+		granteeID     string
+		permission    string
+		authenticated bool
+		want          bool
+	}{
+		// Direct canonical user grants.
+		{private, "reader", PermissionRead, true, true},
+		{private, "reader", PermissionWrite, true, false},
+		{private, "reader", PermissionWriteACP, true, false},
+		{private, "writer", PermissionWrite, true, true},
+		{private, "writer", PermissionReadACP, true, true},
+		{private, "writer", PermissionRead, true, false},
+		{private, "writer", PermissionFullControl, true, false},
+		// FULL_CONTROL implies everything.
+		{private, "admin", PermissionRead, true, true},
+		{private, "admin", PermissionWrite, true, true},
+		{private, "admin", PermissionReadACP, true, true},
+		{private, "admin", PermissionWriteACP, true, true},
+		{private, "admin", PermissionFullControl, true, true},
+		// AuthenticatedUsers applies to authenticated principals only.
+		{private, "stranger", PermissionReadACP, true, true},
+		{private, "stranger", PermissionReadACP, false, false},
+		{private, "", PermissionReadACP, true, true},
+		{private, "", PermissionReadACP, false, false},
+		{private, "stranger", PermissionRead, true, false},
+		// Other groups never match a principal.
+		{private, "stranger", PermissionWrite, true, false},
+		{private, GroupLogDelivery, PermissionWrite, true, false},
+		// Email grants cannot be matched against a canonical ID.
+		{private, "user@example.com", PermissionWriteACP, true, false},
+		// The owner implicitly holds READ_ACP and WRITE_ACP.
+		{private, "owner", PermissionReadACP, true, true},
+		{private, "owner", PermissionWriteACP, true, true},
+		{private, "owner", PermissionRead, true, false},
+		{public, "owner", PermissionWriteACP, false, true},
+		// AllUsers applies to everyone.
+		{public, "", PermissionRead, false, true},
+		{public, "stranger", PermissionRead, true, true},
+		{public, "", PermissionWrite, false, false},
+		{publicFull, "", PermissionWrite, false, true},
+		{publicFull, "", PermissionFullControl, false, true},
+		{authenticatedFull, "stranger", PermissionWriteACP, true, true},
+		{authenticatedFull, "stranger", PermissionWriteACP, false, false},
+		// Invalid inputs.
+		{private, "reader", "read", true, false},
+		{private, "reader", "", true, false},
+		{nil, "reader", PermissionRead, true, false},
+	}
+
+	for i, testCase := range testCases {
+		got := HasPermissionForPrincipal(testCase.policy, testCase.granteeID, testCase.permission, testCase.authenticated)
+		if got != testCase.want {
+			t.Errorf("Test %d: HasPermissionForPrincipal(%q, %q, %v) = %v, expected %v",
+				i+1, testCase.granteeID, testCase.permission, testCase.authenticated, got, testCase.want)
+		}
+		// HasPermission deems any non-empty ID authenticated.
+		if testCase.authenticated == (testCase.granteeID != "") {
+			if got := HasPermission(testCase.policy, testCase.granteeID, testCase.permission); got != testCase.want {
+				t.Errorf("Test %d: HasPermission(%q, %q) = %v, expected %v",
+					i+1, testCase.granteeID, testCase.permission, got, testCase.want)
+			}
+		}
+	}
+}
+
+func TestAccessControlPolicyDecodeValidate(t *testing.T) {
+	testCases := []struct {
+		grantee  GranteeDecode
+		valid    bool
+		repaired string
+	}{
+		{GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "abc", DisplayName: "abc"}, true, GranteeTypeCanonicalUser},
+		{GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}, true, GranteeTypeGroup},
+		{GranteeDecode{Type: GranteeTypeEmail, Email: "bob@example.com"}, true, GranteeTypeEmail},
+		// Type disagreeing with the identity.
+		{GranteeDecode{Type: GranteeTypeGroup, ID: "abc"}, false, GranteeTypeCanonicalUser},
+		{GranteeDecode{Type: GranteeTypeCanonicalUser, URI: GroupAllUsers}, false, GranteeTypeGroup},
+		{GranteeDecode{Type: GranteeTypeCanonicalUser, Email: "bob@example.com"}, false, GranteeTypeEmail},
+		{GranteeDecode{Type: GranteeTypeEmail, ID: "abc"}, false, GranteeTypeCanonicalUser},
+		// Missing or unknown type.
+		{GranteeDecode{ID: "abc"}, false, GranteeTypeCanonicalUser},
+		{GranteeDecode{Type: "User", URI: GroupAllUsers}, false, GranteeTypeGroup},
+		// Ambiguous or missing identity.
+		{GranteeDecode{Type: GranteeTypeGroup, ID: "abc", URI: GroupAllUsers}, false, GranteeTypeGroup},
+		{GranteeDecode{Type: GranteeTypeCanonicalUser, DisplayName: "abc"}, false, GranteeTypeCanonicalUser},
+	}
+
+	for i, testCase := range testCases {
+		acld := &AccessControlPolicyDecode{}
+		acld.AccessControlList.Grants = []GrantDecode{{Grantee: testCase.grantee, Permission: PermissionRead}}
+		err := acld.Validate()
+		if valid := err == nil; valid != testCase.valid {
+			t.Errorf("Test %d: expected valid %v, got %v", i+1, testCase.valid, err)
+		}
+		if err != nil && ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("Test %d: expected InvalidArgument, got %v", i+1, err)
+		}
+
+		repaired := acld.Sanitize()
+		grantee := acld.AccessControlList.Grants[0].Grantee
+		if grantee.Type != testCase.repaired {
+			t.Errorf("Test %d: expected type %s after Sanitize, got %s", i+1, testCase.repaired, grantee.Type)
+		}
+		if wantRepaired := testCase.grantee.Type != testCase.repaired; (repaired == 1) != wantRepaired {
+			t.Errorf("Test %d: expected repaired %v, got %d", i+1, wantRepaired, repaired)
+		}
+		if repaired == 1 && grantee.XMLXSI != grantee.Type {
+			t.Errorf("Test %d: expected xsi:type %s, got %s", i+1, grantee.Type, grantee.XMLXSI)
+		}
+		// Sanitize fixes the type, not the identity.
+		ambiguous := testCase.grantee.ID != "" && testCase.grantee.URI != ""
+		if identityType(grantee) != "" && !ambiguous {
+			if err := acld.Validate(); err != nil {
+				t.Errorf("Test %d: expected a valid policy after Sanitize, got %v", i+1, err)
+			}
+		}
+	}
+}
+
+func TestACLSummary(t *testing.T) {
+	acld := &AccessControlPolicyDecode{}
+	acld.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "owner"}, Permission: PermissionFullControl},
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "abc123"}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{ID: "def456"}, Permission: PermissionReadACP},
+		{Grantee: GranteeDecode{Type: GranteeTypeEmail, Email: "user@example.com"}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{Type: GranteeTypeGroup, URI: GroupLogDelivery}, Permission: PermissionWrite},
+	}
+
+	summary := acld.Summary()
+	if summary.Grants != 6 {
+		t.Errorf("expected 6 grants, got %d", summary.Grants)
+	}
+	wantPermissions := map[string]int{
+		PermissionFullControl: 1,
+		PermissionRead:        3,
+		PermissionReadACP:     1,
+		PermissionWrite:       1,
+	}
+	if !reflect.DeepEqual(summary.Permissions, wantPermissions) {
+		t.Errorf("expected permissions %v, got %v", wantPermissions, summary.Permissions)
+	}
+	wantTypes := map[string]int{
+		GranteeTypeCanonicalUser: 3,
+		GranteeTypeEmail:         1,
+		GranteeTypeGroup:         2,
+	}
+	if !reflect.DeepEqual(summary.GranteeTypes, wantTypes) {
+		t.Errorf("expected grantee types %v, got %v", wantTypes, summary.GranteeTypes)
+	}
+	if !summary.Public {
+		t.Error("expected a public policy")
+	}
+
+	acld.AccessControlList.Grants = acld.AccessControlList.Grants[:4]
+	if summary = acld.Summary(); summary.Public || summary.Grants != 4 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	// AuthenticatedUsers is public too, as in ListPublicObjects.
+	acld.AccessControlList.Grants = append(acld.AccessControlList.Grants, GrantDecode{Grantee: GranteeDecode{Type: GranteeTypeGroup, URI: GroupAuthenticatedUsers}, Permission: PermissionRead})
+	if summary = acld.Summary(); !summary.Public {
+		t.Errorf("expected an AuthenticatedUsers grant to be public, got %+v", summary)
+	}
+	if summary = (&AccessControlPolicyDecode{}).Summary(); summary.Grants != 0 || len(summary.Permissions) != 0 || summary.Public {
+		t.Errorf("unexpected summary of an empty policy %+v", summary)
+	}
+}
+
+func TestGroupedByGrantee(t *testing.T) {
+	alice := GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "alice", DisplayName: "Alice"}
+	email := GranteeDecode{Type: GranteeTypeEmail, Email: "Bob@Example.com"}
+	allUsers := GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}
+
+	acld := &AccessControlPolicyDecode{}
+	acld.AccessControlList.Grants = []GrantDecode{
+		{Grantee: alice, Permission: PermissionWrite},
+		{Grantee: allUsers, Permission: PermissionRead},
+		{Grantee: alice, Permission: PermissionRead},
+		{Grantee: alice, Permission: PermissionFullControl},
+		{Grantee: email, Permission: PermissionReadACP},
+		{Grantee: alice, Permission: PermissionWrite},
+		{Grantee: GranteeDecode{Type: GranteeTypeEmail, Email: "bob@example.com"}, Permission: PermissionRead},
+		{Grantee: allUsers, Permission: PermissionRead},
+	}
+
+	want := []GranteePermissions{
+		{Grantee: alice, Permissions: []string{PermissionFullControl, PermissionRead, PermissionWrite}},
+		{Grantee: allUsers, Permissions: []string{PermissionRead}},
+		{Grantee: email, Permissions: []string{PermissionRead, PermissionReadACP}},
+	}
+	if got := acld.GroupedByGrantee(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got := (&AccessControlPolicyDecode{}).GroupedByGrantee(); len(got) != 0 {
+		t.Fatalf("expected no grantee, got %+v", got)
+	}
+}
+
+func TestACLPolicyClone(t *testing.T) {
+	acle := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	acle.Extensions = []ACLExtension{{
+		XMLName:  xml.Name{Local: "Vendor"},
+		Attrs:    []xml.Attr{{Name: xml.Name{Local: "v"}, Value: "1"}},
+		InnerXML: "x",
+	}}
+	acld := policyEncodeToDecode(acle)
+	acld.Extensions = []ACLExtension{{XMLName: xml.Name{Local: "Vendor"}, Attrs: []xml.Attr{{Name: xml.Name{Local: "v"}, Value: "1"}}, InnerXML: "x"}}
+
+	encodeClone := acle.Clone()
+	if !reflect.DeepEqual(encodeClone, acle) {
+		t.Fatalf("expected an identical clone, got %+v", encodeClone)
+	}
+	encodeClone.Owner.ID = "other"
+	encodeClone.AccessControlList.Grants[0].Grantee.ID = "other"
+	encodeClone.AccessControlList.Grants[1].Permission = PermissionWrite
+	encodeClone.AccessControlList.Grants = append(encodeClone.AccessControlList.Grants, encodeClone.AccessControlList.Grants[0])
+	encodeClone.Extensions[0].Attrs[0].Value = "2"
+
+	decodeClone := acld.Clone()
+	if !reflect.DeepEqual(decodeClone, acld) {
+		t.Fatalf("expected an identical clone, got %+v", decodeClone)
+	}
+	decodeClone.Owner.DisplayName = "other"
+	decodeClone.AccessControlList.Grants[0].Grantee.URI = GroupAllUsers
+	decodeClone.AccessControlList.Grants[1].Permission = PermissionWrite
+	decodeClone.Extensions[0].Attrs[0].Value = "2"
+	decodeClone.Extensions[0].InnerXML = "y"
+
+	if acle.Owner.ID != "owner" || acle.AccessControlList.Grants[0].Grantee.ID != "owner" ||
+		acle.AccessControlList.Grants[1].Permission != PermissionRead || len(acle.AccessControlList.Grants) != 2 ||
+		acle.Extensions[0].Attrs[0].Value != "1" {
+		t.Fatalf("encode clone aliases the original %+v", acle)
+	}
+	if acld.Owner.DisplayName != "" || acld.AccessControlList.Grants[0].Grantee.URI != "" ||
+		acld.AccessControlList.Grants[1].Permission != PermissionRead ||
+		acld.Extensions[0].Attrs[0].Value != "1" || acld.Extensions[0].InnerXML != "x" {
+		t.Fatalf("decode clone aliases the original %+v", acld)
+	}
+
+	if (*AccessControlPolicyEncode)(nil).Clone() != nil || (*AccessControlPolicyDecode)(nil).Clone() != nil {
+		t.Fatal("expected nil clones of nil policies")
+	}
+}
+
+func TestGranteeDisplayMap(t *testing.T) {
+	acld := &AccessControlPolicyDecode{Owner: Owner{ID: "owner", DisplayName: "Alice"}}
+	acld.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "owner"}, Permission: PermissionFullControl},
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "abc123"}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "def456"}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "def456", DisplayName: "Bob"}, Permission: PermissionWrite},
+		{Grantee: GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}, Permission: PermissionRead},
+		{Grantee: GranteeDecode{Type: GranteeTypeEmail, Email: "carol@example.com"}, Permission: PermissionRead},
+	}
+
+	want := map[string]string{
+		"owner":  "Alice",
+		"abc123": "",
+		"def456": "Bob",
+	}
+	if got := acld.GranteeDisplayMap(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// The owner is named by a grant only.
+	acld = &AccessControlPolicyDecode{Owner: Owner{ID: "owner"}}
+	acld.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "owner", DisplayName: "Alice"}, Permission: PermissionFullControl},
+	}
+	if got := acld.GranteeDisplayMap(); !reflect.DeepEqual(got, map[string]string{"owner": "Alice"}) {
+		t.Fatalf("unexpected map %v", got)
+	}
+}
+
+func TestExpandPermissions(t *testing.T) {
+	testCases := []struct {
+		perm     string
+		expected []string
+	}{
+		{PermissionFullControl, []string{PermissionFullControl, PermissionRead, PermissionWrite, PermissionReadACP, PermissionWriteACP}},
+		{PermissionRead, []string{PermissionRead}},
+		{PermissionWriteACP, []string{PermissionWriteACP}},
+		{"READ_WRITE", nil},
+		{"", nil},
+	}
+	for _, testCase := range testCases {
+		if got := ExpandPermissions(testCase.perm); !reflect.DeepEqual(got, testCase.expected) {
+			t.Errorf("%q: expected %v, got %v", testCase.perm, testCase.expected, got)
+		}
+	}
+}
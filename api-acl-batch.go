@@ -0,0 +1,323 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"sync"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// defaultBatchACLConcurrency is the default number of concurrent
+// requests issued by the batch ACL operations.
+const defaultBatchACLConcurrency = 8
+
+// BatchACLOptions holds options for the batch ACL operations.
+type BatchACLOptions struct {
+	// Concurrency is the maximum number of concurrent requests,
+	// defaults to 8.
+	Concurrency int
+
+	// SkipStat avoids the StatObject request of GetObjectsACL, see
+	// GetObjectACLOptions.SkipStat.
+	SkipStat bool
+
+	// MaxResults, when positive, caps the number of results streamed by
+	// ListPublicObjects.
+	MaxResults int
+}
+
+func (opts BatchACLOptions) concurrency() int {
+	if opts.Concurrency <= 0 {
+		return defaultBatchACLConcurrency
+	}
+	return opts.Concurrency
+}
+
+// ACLResult is the outcome of an ACL operation on a single object.
+type ACLResult struct {
+	ObjectName string
+	Err        error
+}
+
+// forEachConcurrent calls fn for every index in [0, n) from at most
+// concurrency goroutines. No new call is started once ctx is done; it
+// returns when all started calls have returned.
+func forEachConcurrent(ctx context.Context, n, concurrency int, fn func(i int)) {
+	indexCh := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexCh {
+				fn(i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case indexCh <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexCh)
+	wg.Wait()
+}
+
+// SetObjectsACLCanned sets the canned ACL on every given object with a
+// bounded number of concurrent requests and streams one result per
+// object. A failing object does not stop the others. Once ctx is done
+// no new request is started and the channel is closed promptly; the
+// objects left over are not reported.
+func (c *Client) SetObjectsACLCanned(ctx context.Context, bucketName string, objects []string, cannedACL string, opts BatchACLOptions) <-chan ACLResult {
+	resultCh := make(chan ACLResult)
+	go func() {
+		defer close(resultCh)
+		forEachConcurrent(ctx, len(objects), opts.concurrency(), func(i int) {
+			err := c.PutObjectACLCanned(ctx, bucketName, objects[i], cannedACL)
+			select {
+			case resultCh <- ACLResult{ObjectName: objects[i], Err: err}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return resultCh
+}
+
+// ObjectACLResult is the ACL of a single object read by GetObjectsACL.
+type ObjectACLResult struct {
+	Object string
+	Info   *ObjectInfo
+	Err    error
+}
+
+// GetObjectsACL reads the ACL of every given object with a bounded
+// number of concurrent requests and streams one result per object, in
+// no particular order. A failing object does not stop the others. Once
+// ctx is done no new request is started and the channel is closed
+// promptly; the objects left over are not reported.
+func (c *Client) GetObjectsACL(ctx context.Context, bucketName string, objects []string, opts BatchACLOptions) <-chan ObjectACLResult {
+	resultCh := make(chan ObjectACLResult)
+	go func() {
+		defer close(resultCh)
+		forEachConcurrent(ctx, len(objects), opts.concurrency(), func(i int) {
+			info, err := c.GetObjectACLWithOptions(ctx, bucketName, objects[i], GetObjectACLOptions{SkipStat: opts.SkipStat})
+			select {
+			case resultCh <- ObjectACLResult{Object: objects[i], Info: info, Err: err}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return resultCh
+}
+
+// BucketACLResult is the ACL of a single bucket read by GetAllBucketACLs.
+type BucketACLResult struct {
+	Bucket string
+	Info   *BucketACLInfo
+	Err    error
+}
+
+// GetAllBucketACLs lists the buckets of the account and reads the ACL of
+// each one, see GetBucketACLInfo, with a bounded number of concurrent
+// requests. One result is streamed per bucket, in no particular order,
+// and a failing bucket does not stop the others. When the buckets cannot
+// be listed, a single result with an empty Bucket carries the error.
+// Once ctx is done no new request is started and the channel is closed
+// promptly; the buckets left over are not reported.
+func (c *Client) GetAllBucketACLs(ctx context.Context, opts BatchACLOptions) <-chan BucketACLResult {
+	resultCh := make(chan BucketACLResult)
+	go func() {
+		defer close(resultCh)
+		buckets, err := c.ListBuckets(ctx)
+		if err != nil {
+			select {
+			case resultCh <- BucketACLResult{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		forEachConcurrent(ctx, len(buckets), opts.concurrency(), func(i int) {
+			info, err := c.GetBucketACLInfo(ctx, buckets[i].Name)
+			select {
+			case resultCh <- BucketACLResult{Bucket: buckets[i].Name, Info: info, Err: err}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return resultCh
+}
+
+// VersionACLResult is the outcome of setting the ACL of a single object
+// version with PutObjectACLAllVersions.
+type VersionACLResult struct {
+	VersionID string
+	Err       error
+}
+
+// PutObjectACLAllVersions sets the ACL of every version of an object,
+// see PutObjectACLAllVersionsWithOptions.
+func (c *Client) PutObjectACLAllVersions(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode) ([]VersionACLResult, error) {
+	return c.PutObjectACLAllVersionsWithOptions(ctx, bucketName, objectName, acle, BatchACLOptions{})
+}
+
+// PutObjectACLAllVersionsWithOptions lists the versions of an object and
+// sets the ACL on each one with a bounded number of concurrent requests.
+// Delete markers are skipped. One result is returned per version, in
+// listing order, and a failing version does not stop the others. The
+// error is only set when the versions cannot be listed. Once ctx is done
+// no new request is started and the versions left over carry ctx.Err().
+func (c *Client) PutObjectACLAllVersionsWithOptions(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode, opts BatchACLOptions) ([]VersionACLResult, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, err
+	}
+	if acle == nil {
+		return nil, errInvalidArgument("ACL policy cannot be nil.")
+	}
+	if err := acle.validate(); err != nil {
+		return nil, err
+	}
+
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var results []VersionACLResult
+	for obj := range c.ListObjects(listCtx, bucketName, ListObjectsOptions{
+		WithVersions: true,
+		Prefix:       objectName,
+		Recursive:    true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		if obj.Key != objectName || obj.IsDeleteMarker {
+			continue
+		}
+		results = append(results, VersionACLResult{VersionID: obj.VersionID})
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	started := make([]bool, len(results))
+	forEachConcurrent(ctx, len(results), opts.concurrency(), func(i int) {
+		started[i] = true
+		results[i].Err = c.PutObjectACLWithOptions(ctx, bucketName, objectName, acle, PutObjectACLOptions{VersionID: results[i].VersionID})
+	})
+	for i := range results {
+		if !started[i] {
+			results[i].Err = ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+// PublicObjectResult is a publicly accessible object reported by
+// ListPublicObjects, with the grants held by the AllUsers and
+// AuthenticatedUsers groups.
+type PublicObjectResult struct {
+	Object string
+	Grants []GrantDecode
+	Err    error
+}
+
+// publicGrants returns the grants of the policy held by the AllUsers
+// and AuthenticatedUsers groups.
+func publicGrants(acld *AccessControlPolicyDecode) []GrantDecode {
+	var grants []GrantDecode
+	for _, g := range acld.AccessControlList.Grants {
+		if isPublicGroup(g.Grantee.URI) {
+			grants = append(grants, g)
+		}
+	}
+	return grants
+}
+
+// ListPublicObjects lists the objects under prefix, reads the ACL of
+// each one with a bounded number of concurrent requests and streams the
+// objects granting any permission to the AllUsers or AuthenticatedUsers
+// groups, in no particular order. An object whose ACL cannot be read is
+// reported with its error, a listing failure with an empty Object. At
+// most opts.MaxResults results are streamed when set. Once ctx is done
+// no new request is started and the channel is closed promptly.
+func (c *Client) ListPublicObjects(ctx context.Context, bucketName, prefix string, opts BatchACLOptions) <-chan PublicObjectResult {
+	resultCh := make(chan PublicObjectResult)
+	go func() {
+		defer close(resultCh)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			mu   sync.Mutex
+			sent int
+		)
+		// emit streams res unless the cap is reached, canceling the
+		// listing once it is.
+		emit := func(res PublicObjectResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Err() != nil || opts.MaxResults > 0 && sent >= opts.MaxResults {
+				return
+			}
+			select {
+			case resultCh <- res:
+				sent++
+				if sent == opts.MaxResults {
+					cancel()
+				}
+			case <-ctx.Done():
+			}
+		}
+
+		objectCh := c.ListObjects(ctx, bucketName, ListObjectsOptions{Prefix: prefix, Recursive: true})
+		var wg sync.WaitGroup
+		for w := 0; w < opts.concurrency(); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for obj := range objectCh {
+					if ctx.Err() != nil {
+						continue
+					}
+					if obj.Err != nil {
+						emit(PublicObjectResult{Err: obj.Err})
+						continue
+					}
+					acld, err := c.getACLPolicy(ctx, bucketName, obj.Key, "", nil, ACLOptions{})
+					if err != nil {
+						emit(PublicObjectResult{Object: obj.Key, Err: err})
+						continue
+					}
+					if grants := publicGrants(acld); len(grants) > 0 {
+						emit(PublicObjectResult{Object: obj.Key, Grants: grants})
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	return resultCh
+}
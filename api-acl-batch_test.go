@@ -0,0 +1,365 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetObjectsACLCanned(t *testing.T) {
+	var inFlight, maxInFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if strings.HasSuffix(r.URL.Path, "/object-7") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	var objects []string
+	for i := 0; i < 40; i++ {
+		objects = append(objects, fmt.Sprintf("object-%d", i))
+	}
+
+	seen := make(map[string]bool)
+	for res := range clnt.SetObjectsACLCanned(context.Background(), "bucket", objects, "public-read", BatchACLOptions{Concurrency: 4}) {
+		seen[res.ObjectName] = true
+		if res.ObjectName == "object-7" {
+			if ToErrorResponse(res.Err).Code != "AccessDenied" {
+				t.Errorf("expected AccessDenied for %s, got %v", res.ObjectName, res.Err)
+			}
+		} else if res.Err != nil {
+			t.Errorf("unexpected error for %s: %v", res.ObjectName, res.Err)
+		}
+	}
+	if len(seen) != len(objects) {
+		t.Fatalf("expected %d results, got %d", len(objects), len(seen))
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 4 {
+		t.Fatalf("expected at most 4 concurrent requests, got %d", max)
+	}
+}
+
+func TestSetObjectsACLCannedCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	objects := make([]string, 1000)
+	for i := range objects {
+		objects[i] = fmt.Sprintf("object-%d", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resultCh := clnt.SetObjectsACLCanned(ctx, "bucket", objects, "private", BatchACLOptions{Concurrency: 2})
+	<-resultCh
+	cancel()
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for range resultCh {
+			n++
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n >= len(objects)-1 {
+			t.Fatalf("expected the batch to stop early, got %d more results", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("result channel was not closed after cancellation")
+	}
+}
+
+func TestGetObjectsACL(t *testing.T) {
+	stub := newACLTestServer()
+	var objects []string
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%d", i)
+		objects = append(objects, object)
+		// Every third object does not exist.
+		if i%3 != 0 {
+			stub.acls["/bucket/"+object+"?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+		}
+	}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	seen := make(map[string]bool)
+	for res := range clnt.GetObjectsACL(context.Background(), "bucket", objects, BatchACLOptions{Concurrency: 4, SkipStat: true}) {
+		if seen[res.Object] {
+			t.Fatalf("duplicate result for %s", res.Object)
+		}
+		seen[res.Object] = true
+		var i int
+		fmt.Sscanf(res.Object, "object-%d", &i)
+		if i%3 == 0 {
+			if ToErrorResponse(res.Err).Code != "NoSuchKey" || res.Info != nil {
+				t.Errorf("%s: expected NoSuchKey, got %v", res.Object, res.Err)
+			}
+			continue
+		}
+		if res.Err != nil {
+			t.Errorf("%s: %v", res.Object, res.Err)
+			continue
+		}
+		if res.Info.Key != res.Object || res.Info.Metadata.Get("X-Amz-Acl") != "private" {
+			t.Errorf("%s: unexpected info %+v", res.Object, res.Info)
+		}
+	}
+	if len(seen) != len(objects) {
+		t.Fatalf("expected %d results, got %d", len(objects), len(seen))
+	}
+	if n := stub.count(http.MethodHead); n != 0 {
+		t.Fatalf("expected no StatObject request, got %d", n)
+	}
+}
+
+func TestGetAllBucketACLs(t *testing.T) {
+	stub := newACLTestServer()
+	var buckets strings.Builder
+	for i := 0; i < 10; i++ {
+		bucket := fmt.Sprintf("bucket-%d", i)
+		fmt.Fprintf(&buckets, "<Bucket><Name>%s</Name><CreationDate>2022-01-01T00:00:00.000Z</CreationDate></Bucket>", bucket)
+		// Every third bucket denies access to its ACL.
+		if i%3 != 0 {
+			stub.acls["/"+bucket+"/?versionId="] = testBucketACLXML
+		}
+	}
+	var listFails int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			if atomic.LoadInt32(&listFails) == 1 {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+				return
+			}
+			w.Write([]byte(`<ListAllMyBucketsResult><Owner><ID>owner</ID></Owner><Buckets>` + buckets.String() + `</Buckets></ListAllMyBucketsResult>`))
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	seen := make(map[string]bool)
+	for res := range clnt.GetAllBucketACLs(context.Background(), BatchACLOptions{Concurrency: 3}) {
+		if seen[res.Bucket] {
+			t.Fatalf("duplicate result for %s", res.Bucket)
+		}
+		seen[res.Bucket] = true
+		var i int
+		fmt.Sscanf(res.Bucket, "bucket-%d", &i)
+		if i%3 == 0 {
+			if ToErrorResponse(res.Err).Code != "NoSuchKey" || res.Info != nil {
+				t.Errorf("%s: expected an error, got %v", res.Bucket, res.Err)
+			}
+			continue
+		}
+		if res.Err != nil {
+			t.Errorf("%s: %v", res.Bucket, res.Err)
+			continue
+		}
+		if res.Info.CannedACL != "public-read" {
+			t.Errorf("%s: unexpected info %+v", res.Bucket, res.Info)
+		}
+	}
+	if len(seen) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(seen))
+	}
+
+	atomic.StoreInt32(&listFails, 1)
+	var results []BucketACLResult
+	for res := range clnt.GetAllBucketACLs(context.Background(), BatchACLOptions{}) {
+		results = append(results, res)
+	}
+	if len(results) != 1 || results[0].Bucket != "" || ToErrorResponse(results[0].Err).Code != "AccessDenied" {
+		t.Fatalf("expected a single AccessDenied result, got %+v", results)
+	}
+
+	// A cancelled context closes the channel without scanning.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&listFails, 0)
+	for res := range clnt.GetAllBucketACLs(ctx, BatchACLOptions{}) {
+		if res.Err == nil {
+			t.Fatalf("unexpected result %+v after cancellation", res)
+		}
+	}
+}
+
+func TestPutObjectACLAllVersions(t *testing.T) {
+	const listing = `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Prefix>object</Prefix>
+  <IsTruncated>false</IsTruncated>
+  <Version><Key>object</Key><VersionId>v3</VersionId><IsLatest>true</IsLatest></Version>
+  <DeleteMarker><Key>object</Key><VersionId>dm</VersionId><IsLatest>false</IsLatest></DeleteMarker>
+  <Version><Key>object</Key><VersionId>v2</VersionId><IsLatest>false</IsLatest></Version>
+  <Version><Key>object</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest></Version>
+  <Version><Key>object-other</Key><VersionId>x1</VersionId><IsLatest>true</IsLatest></Version>
+</ListVersionsResult>`
+	var mu sync.Mutex
+	var puts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if _, ok := query["versions"]; ok {
+			w.Write([]byte(listing))
+			return
+		}
+		if _, ok := query["acl"]; !ok || r.Method != http.MethodPut || r.URL.Path != "/bucket/object" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			return
+		}
+		versionID := query.Get("versionId")
+		mu.Lock()
+		puts = append(puts, versionID)
+		mu.Unlock()
+		if versionID == "v2" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+		}
+	}))
+	defer srv.Close()
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	results, err := newACLTestClient(t, srv).PutObjectACLAllVersionsWithOptions(context.Background(), "bucket", "object", acle, BatchACLOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", results)
+	}
+	for i, versionID := range []string{"v3", "v2", "v1"} {
+		res := results[i]
+		if res.VersionID != versionID {
+			t.Errorf("result %d: expected version %s, got %s", i, versionID, res.VersionID)
+		}
+		if versionID == "v2" {
+			if ToErrorResponse(res.Err).Code != "AccessDenied" {
+				t.Errorf("expected AccessDenied for v2, got %v", res.Err)
+			}
+		} else if res.Err != nil {
+			t.Errorf("unexpected error for %s: %v", versionID, res.Err)
+		}
+	}
+	if len(puts) != 3 {
+		t.Fatalf("expected 3 PUT requests, got %v", puts)
+	}
+}
+
+func TestPutObjectACLAllVersionsCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	if _, err := newACLTestClient(t, srv).PutObjectACLAllVersions(ctx, "bucket", "object", acle); err == nil {
+		t.Fatal("expected an error for a canceled context")
+	}
+}
+
+func TestListPublicObjects(t *testing.T) {
+	stub := newACLTestServer()
+	objects := map[string]string{
+		"docs/public-read":      testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testGroupGrantXML(GroupAllUsers, "READ")),
+		"docs/private":          testACLXML(testUserGrantXML("owner", "FULL_CONTROL")),
+		"docs/authenticated":    testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testGroupGrantXML(GroupAuthenticatedUsers, "WRITE")),
+		"docs/shared":           testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("abc123", "READ")),
+		"docs/public-read-acp":  testACLXML(testGroupGrantXML(GroupAllUsers, "READ_ACP"), testGroupGrantXML(GroupLogDelivery, "WRITE")),
+		"docs/private-delivery": testACLXML(testGroupGrantXML(GroupLogDelivery, "WRITE")),
+	}
+	listing := `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><Prefix>docs/</Prefix><IsTruncated>false</IsTruncated>`
+	for object, acl := range objects {
+		stub.acls["/bucket/"+object+"?versionId="] = acl
+		listing += "<Contents><Key>" + object + "</Key><Size>1</Size></Contents>"
+	}
+	listing += "</ListBucketResult>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["list-type"]; ok {
+			if prefix := r.URL.Query().Get("prefix"); prefix != "docs/" {
+				t.Errorf("unexpected prefix %q", prefix)
+			}
+			w.Write([]byte(listing))
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	public := make(map[string]string)
+	for res := range clnt.ListPublicObjects(context.Background(), "bucket", "docs/", BatchACLOptions{Concurrency: 3}) {
+		if res.Err != nil {
+			t.Fatalf("%s: %v", res.Object, res.Err)
+		}
+		var grants []string
+		for _, g := range res.Grants {
+			grants = append(grants, grantKey(g.ToEncode()))
+		}
+		public[res.Object] = strings.Join(grants, ",")
+	}
+	want := map[string]string{
+		"docs/public-read":     "uri=" + GroupAllUsers + " READ",
+		"docs/authenticated":   "uri=" + GroupAuthenticatedUsers + " WRITE",
+		"docs/public-read-acp": "uri=" + GroupAllUsers + " READ_ACP",
+	}
+	if !reflect.DeepEqual(public, want) {
+		t.Fatalf("expected public objects %v, got %v", want, public)
+	}
+
+	n := 0
+	for res := range clnt.ListPublicObjects(context.Background(), "bucket", "docs/", BatchACLOptions{Concurrency: 3, MaxResults: 2}) {
+		if _, ok := want[res.Object]; !ok || res.Err != nil {
+			t.Errorf("unexpected result %+v", res)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 results with MaxResults, got %d", n)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// getServiceOwner returns the owner reported by a ListBuckets request,
+// i.e. the canonical user of the client credentials.
+func (c *Client) getServiceOwner(ctx context.Context) (owner, error) {
+	// Execute GET on service.
+	resp, err := c.executeMethod(ctx, http.MethodGet, requestMetadata{contentSHA256Hex: emptySHA256Hex})
+	defer closeResponse(resp)
+	if err != nil {
+		return owner{}, err
+	}
+	if resp != nil {
+		if resp.StatusCode != http.StatusOK {
+			return owner{}, httpRespToErrorResponse(resp, "", "")
+		}
+	}
+	listAllMyBucketsResult := listAllMyBucketsResult{}
+	if err = xmlDecoder(resp.Body, &listAllMyBucketsResult); err != nil {
+		return owner{}, err
+	}
+	return listAllMyBucketsResult.Owner, nil
+}
+
+// ResolveGranteeName returns the display name of the canonical user
+// canonicalID. S3 offers no directory of canonical users, so only the
+// user of the client credentials can be resolved; an empty name is
+// returned for any other ID.
+func (c *Client) ResolveGranteeName(ctx context.Context, canonicalID string) (string, error) {
+	if canonicalID == "" {
+		return "", errInvalidArgument("Canonical ID cannot be empty.")
+	}
+	o, err := c.getServiceOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+	if o.ID != canonicalID {
+		return "", nil
+	}
+	return o.DisplayName, nil
+}
+
+// CanonicalIDForDisplayName returns the canonical ID of the user with
+// the given display name, on a best-effort basis. Like
+// ResolveGranteeName, only the user of the client credentials can be
+// resolved; ErrDisplayNameNotResolved is returned for any other name.
+// Some gateways report no display names at all, in which case nothing
+// resolves.
+func (c *Client) CanonicalIDForDisplayName(ctx context.Context, displayName string) (string, error) {
+	if displayName == "" {
+		return "", errInvalidArgument("Display name cannot be empty.")
+	}
+	o, err := c.getServiceOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+	if o.DisplayName != displayName || o.ID == "" {
+		return "", fmt.Errorf("%w: %q", ErrDisplayNameNotResolved, displayName)
+	}
+	return o.ID, nil
+}
+
+// resolveGranteeNames fills the missing display names of the canonical
+// user grantees. As with ResolveGranteeName, only the user of the client
+// credentials can be resolved, so the service owner is looked up once,
+// and only when a name is missing. A failed lookup is ignored and leaves
+// the names empty.
+func (c *Client) resolveGranteeNames(ctx context.Context, grants []GrantDecode) {
+	missing := false
+	for _, g := range grants {
+		if g.Grantee.ID != "" && g.Grantee.DisplayName == "" {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return
+	}
+	o, err := c.getServiceOwner(ctx)
+	if err != nil || o.ID == "" {
+		return
+	}
+	for i := range grants {
+		grantee := &grants[i].Grantee
+		if grantee.ID == o.ID && grantee.DisplayName == "" {
+			grantee.DisplayName = o.DisplayName
+		}
+	}
+}
+
+// SetGranteePermission sets the permission of the canonical user
+// granteeID in the ACL of an object, replacing the grants it already
+// holds or adding a new one, and leaves the owner and the other grants
+// untouched. An empty newPermission removes the grantee from the ACL.
+// Nothing is sent when the ACL already matches.
+func (c *Client) SetGranteePermission(ctx context.Context, bucketName, objectName, granteeID, newPermission string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	if granteeID == "" {
+		return errInvalidArgument("Grantee ID cannot be empty.")
+	}
+	if newPermission != "" && !validACLPermissions[newPermission] {
+		return errInvalidArgument(fmt.Sprintf("Invalid permission %q.", newPermission))
+	}
+	return c.updateACL(ctx, bucketName, objectName, func(acle *AccessControlPolicyEncode) error {
+		return setGranteePermission(acle, granteeID, newPermission)
+	}, UpdateACLOptions{})
+}
+
+// setGranteePermission is the mutate function of SetGranteePermission.
+// The first grant of the grantee is updated in place so the grant order
+// is preserved.
+func setGranteePermission(acle *AccessControlPolicyEncode, granteeID, permission string) error {
+	var (
+		grants  []GrantEncode
+		found   bool
+		changed bool
+	)
+	for _, g := range acle.AccessControlList.Grants {
+		if g.Grantee.ID != granteeID || granteeType(g.Grantee) != GranteeTypeCanonicalUser {
+			grants = append(grants, g)
+			continue
+		}
+		if found || permission == "" {
+			changed = true
+			continue
+		}
+		found = true
+		if g.Permission != permission {
+			g.Permission = permission
+			changed = true
+		}
+		grants = append(grants, g)
+	}
+	if !found && permission != "" {
+		grantee := newGranteeEncode(GranteeTypeCanonicalUser)
+		grantee.ID = granteeID
+		grants = append(grants, GrantEncode{Grantee: grantee, Permission: permission})
+		changed = true
+	}
+	if !changed {
+		return errACLUnchanged
+	}
+	acle.AccessControlList.Grants = grants
+	return nil
+}
+
+// GiveBucketOwnerControl adds a FULL_CONTROL grant to the bucket owner
+// bucketOwnerID in the ACL of an object, e.g. after a cross-account
+// upload, like the bucket-owner-full-control canned ACL but keeping the
+// owner and the other grants. Nothing is sent when the grant already
+// exists.
+func (c *Client) GiveBucketOwnerControl(ctx context.Context, bucketName, objectName, bucketOwnerID string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	if bucketOwnerID == "" {
+		return errInvalidArgument("Bucket owner ID cannot be empty.")
+	}
+	return c.updateACL(ctx, bucketName, objectName, func(acle *AccessControlPolicyEncode) error {
+		grantee := newGranteeEncode(GranteeTypeCanonicalUser)
+		grantee.ID = bucketOwnerID
+		if !acle.AddGrant(GrantEncode{Grantee: grantee, Permission: PermissionFullControl}) {
+			return errACLUnchanged
+		}
+		return nil
+	}, UpdateACLOptions{})
+}
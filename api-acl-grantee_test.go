@@ -0,0 +1,164 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetGranteePermission(t *testing.T) {
+	testCases := []struct {
+		name       string
+		granteeID  string
+		permission string
+		expected   string
+		puts       int
+	}{
+		{"upgrade", "alice", PermissionWrite, "owner FULL_CONTROL,alice WRITE,group READ", 1},
+		{"downgrade", "owner", PermissionRead, "owner READ,alice READ,group READ", 1},
+		{"add", "bob", PermissionReadACP, "owner FULL_CONTROL,alice READ,group READ,bob READ_ACP", 1},
+		{"remove", "alice", "", "owner FULL_CONTROL,group READ", 1},
+		{"unchanged", "alice", PermissionRead, "owner FULL_CONTROL,alice READ,group READ", 0},
+		{"remove-missing", "bob", "", "owner FULL_CONTROL,alice READ,group READ", 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stub := newACLTestServer()
+			stub.acls["/bucket/object?versionId="] = testACLXML(
+				testUserGrantXML("owner", PermissionFullControl),
+				testUserGrantXML("alice", PermissionRead),
+				testGroupGrantXML(GroupAllUsers, PermissionRead),
+			)
+			srv := httptest.NewServer(stub)
+			defer srv.Close()
+			clnt := newACLTestClient(t, srv)
+			ctx := context.Background()
+
+			if err := clnt.SetGranteePermission(ctx, "bucket", "object", testCase.granteeID, testCase.permission); err != nil {
+				t.Fatal(err)
+			}
+			acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if acld.Owner.ID != "owner" {
+				t.Fatalf("unexpected owner %+v", acld.Owner)
+			}
+			var grants []string
+			for _, g := range acld.AccessControlList.Grants {
+				grantee := g.Grantee.ID
+				if g.Grantee.URI != "" {
+					grantee = "group"
+				}
+				grants = append(grants, grantee+" "+g.Permission)
+			}
+			if strings.Join(grants, ",") != testCase.expected {
+				t.Fatalf("expected grants %s, got %s", testCase.expected, strings.Join(grants, ","))
+			}
+			if n := stub.count(http.MethodPut); n != testCase.puts {
+				t.Fatalf("expected %d PUT requests, got %d", testCase.puts, n)
+			}
+		})
+	}
+}
+
+func TestSetGranteePermissionInvalid(t *testing.T) {
+	clnt, err := New("localhost:9000", &Options{Region: "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][2]string{{"", PermissionRead}, {"alice", "READ_WRITE"}} {
+		err = clnt.SetGranteePermission(context.Background(), "bucket", "object", args[0], args[1])
+		if ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("expected InvalidArgument for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestCanonicalIDForDisplayName(t *testing.T) {
+	stub := newACLTestServer()
+	stub.owner = Owner{ID: "owner-id", DisplayName: "Owner"}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	id, err := clnt.CanonicalIDForDisplayName(ctx, "Owner")
+	if err != nil || id != "owner-id" {
+		t.Fatalf("expected owner-id, got %q (%v)", id, err)
+	}
+	if _, err = clnt.CanonicalIDForDisplayName(ctx, "Someone"); !errors.Is(err, ErrDisplayNameNotResolved) {
+		t.Fatalf("expected ErrDisplayNameNotResolved, got %v", err)
+	}
+
+	policy, err := NewACLBuilder(Owner{ID: "owner-id"}).GrantDisplayName("Owner", PermissionFullControl).BuildResolved(ctx, clnt.CanonicalIDForDisplayName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := policy.AccessControlList.Grants[0].Grantee.ID; id != "owner-id" {
+		t.Fatalf("expected owner-id, got %q", id)
+	}
+}
+
+func TestGiveBucketOwnerControl(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(
+		testUserGrantXML("owner", PermissionFullControl),
+		testUserGrantXML("bucket-owner", PermissionRead),
+		testGroupGrantXML(GroupAllUsers, PermissionRead),
+	)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := clnt.GiveBucketOwnerControl(ctx, "bucket", "object", "bucket-owner"); err != nil {
+			t.Fatal(err)
+		}
+		// The second call finds the grant and sends nothing.
+		if n := stub.count(http.MethodPut); n != 1 {
+			t.Fatalf("call %d: expected a single PUT request, got %d", i+1, n)
+		}
+		acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acld.Owner.ID != "owner" {
+			t.Fatalf("unexpected owner %+v", acld.Owner)
+		}
+		var grants []string
+		for _, g := range acld.AccessControlList.Grants {
+			grants = append(grants, grantKey(g.ToEncode()))
+		}
+		want := "id=owner FULL_CONTROL,id=bucket-owner READ,uri=" + GroupAllUsers + " READ,id=bucket-owner FULL_CONTROL"
+		if strings.Join(grants, ",") != want {
+			t.Fatalf("call %d: expected grants %s, got %s", i+1, want, strings.Join(grants, ","))
+		}
+	}
+
+	if err := clnt.GiveBucketOwnerControl(ctx, "bucket", "object", ""); ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"encoding/xml"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// accessControlPolicyOwner decodes only the owner of an
+// AccessControlPolicy document.
+type accessControlPolicyOwner struct {
+	XMLName xml.Name `xml:"AccessControlPolicy"`
+	Owner   Owner    `xml:"Owner"`
+}
+
+// getACLOwner fetches the ACL of a bucket, or of an object when
+// objectName is non-empty, and decodes only its owner.
+func (c *Client) getACLOwner(ctx context.Context, bucketName, objectName string) (Owner, error) {
+	buf, _, err := c.getACL(ctx, bucketName, objectName, "", nil, ACLOptions{})
+	if err != nil {
+		return Owner{}, err
+	}
+	defer putACLBuffer(buf)
+	res := accessControlPolicyOwner{}
+	if err = decodeACLBody(buf.Bytes(), &res); err != nil {
+		return Owner{}, err
+	}
+	return Owner{ID: res.Owner.ID, DisplayName: res.Owner.DisplayName}, nil
+}
+
+// GetObjectOwner returns the owner of an object as reported by its ACL,
+// without processing the grants nor issuing a StatObject request.
+func (c *Client) GetObjectOwner(ctx context.Context, bucketName, objectName string) (Owner, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return Owner{}, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return Owner{}, err
+	}
+	return c.getACLOwner(ctx, bucketName, objectName)
+}
+
+// GetBucketOwner returns the owner of a bucket as reported by its ACL.
+func (c *Client) GetBucketOwner(ctx context.Context, bucketName string) (Owner, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return Owner{}, err
+	}
+	return c.getACLOwner(ctx, bucketName, "")
+}
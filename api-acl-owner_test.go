@@ -0,0 +1,58 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetObjectOwner(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	stub.acls["/bucket/?versionId="] = testBucketACLXML
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	owner, err := clnt.GetObjectOwner(ctx, "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != (Owner{ID: "owner", DisplayName: "owner"}) {
+		t.Fatalf("unexpected object owner %+v", owner)
+	}
+
+	owner, err = clnt.GetBucketOwner(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.ID != "75aa57f09aa0c8caeab4f8c24e99d10f8e7faeebf76c078efc7c6caea54ba06a" || owner.DisplayName != "mtd@amazon.com" {
+		t.Fatalf("unexpected bucket owner %+v", owner)
+	}
+
+	if n := stub.count(http.MethodHead); n != 0 {
+		t.Fatalf("expected no StatObject request, got %d", n)
+	}
+	if _, err = clnt.GetObjectOwner(ctx, "bucket", "missing"); ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Fatalf("expected NoSuchKey, got %v", err)
+	}
+}
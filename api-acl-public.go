@@ -0,0 +1,163 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// addPublicReadGrant is the mutate function adding the AllUsers READ
+// grant to a policy, leaving the policy untouched when already present.
+func addPublicReadGrant(acle *AccessControlPolicyEncode) error {
+	grantee := newGranteeEncode(GranteeTypeGroup)
+	grantee.URI = GroupAllUsers
+	if !acle.AddGrant(GrantEncode{Grantee: grantee, Permission: PermissionRead}) {
+		return errACLUnchanged
+	}
+	return nil
+}
+
+// AddPublicReadGrant makes an object readable by anyone by adding the
+// AllUsers READ grant to its ACL. Unlike setting the public-read canned
+// ACL, the other grants are kept. Nothing is sent when the grant already
+// exists.
+func (c *Client) AddPublicReadGrant(ctx context.Context, bucketName, objectName string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	return c.updateACL(ctx, bucketName, objectName, addPublicReadGrant, UpdateACLOptions{})
+}
+
+// AddBucketPublicReadGrant adds the AllUsers READ grant to the ACL of a
+// bucket, keeping the other grants. Nothing is sent when the grant
+// already exists.
+func (c *Client) AddBucketPublicReadGrant(ctx context.Context, bucketName string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	return c.updateACL(ctx, bucketName, "", addPublicReadGrant, UpdateACLOptions{})
+}
+
+// hasAllUsersGrant reports whether the policy grants perm, or
+// FULL_CONTROL, to the AllUsers group.
+func hasAllUsersGrant(acld *AccessControlPolicyDecode, perm string) bool {
+	for _, g := range acld.AccessControlList.Grants {
+		if g.Grantee.URI != GroupAllUsers {
+			continue
+		}
+		if g.Permission == perm || g.Permission == PermissionFullControl {
+			return true
+		}
+	}
+	return false
+}
+
+// IsObjectPublic reports whether anyone can read the object, that is
+// whether its ACL grants READ or FULL_CONTROL to the AllUsers group.
+func (c *Client) IsObjectPublic(ctx context.Context, bucketName, objectName string) (bool, error) {
+	return c.isObjectPublic(ctx, bucketName, objectName, PermissionRead)
+}
+
+// IsObjectPublicWrite reports whether the ACL of the object grants
+// WRITE or FULL_CONTROL to the AllUsers group.
+func (c *Client) IsObjectPublicWrite(ctx context.Context, bucketName, objectName string) (bool, error) {
+	return c.isObjectPublic(ctx, bucketName, objectName, PermissionWrite)
+}
+
+// isObjectPublic reports whether AllUsers is granted perm on the object.
+func (c *Client) isObjectPublic(ctx context.Context, bucketName, objectName, perm string) (bool, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return false, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return false, err
+	}
+	acld, err := c.getACLPolicy(ctx, bucketName, objectName, "", nil, ACLOptions{})
+	if err != nil {
+		return false, err
+	}
+	return hasAllUsersGrant(acld, perm), nil
+}
+
+// removePublicGrants is the mutate function removing the grants held by
+// the AllUsers and AuthenticatedUsers groups.
+func removePublicGrants(acle *AccessControlPolicyEncode) error {
+	grants := acle.AccessControlList.Grants[:0]
+	for _, g := range acle.AccessControlList.Grants {
+		if isPublicGroup(g.Grantee.URI) {
+			continue
+		}
+		grants = append(grants, g)
+	}
+	if len(grants) == len(acle.AccessControlList.Grants) {
+		return errACLUnchanged
+	}
+	acle.AccessControlList.Grants = grants
+	return nil
+}
+
+// RemovePublicAccess removes from the ACL of an object every grant held
+// by the AllUsers or AuthenticatedUsers groups, keeping the owner and
+// account grants. Nothing is sent when there is no such grant.
+func (c *Client) RemovePublicAccess(ctx context.Context, bucketName, objectName string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	return c.updateACL(ctx, bucketName, objectName, removePublicGrants, UpdateACLOptions{})
+}
+
+// makePrivate is the mutate function reducing a policy to the private
+// canned ACL, a single FULL_CONTROL grant to the owner.
+func makePrivate(acle *AccessControlPolicyEncode) error {
+	grants := acle.AccessControlList.Grants
+	if len(grants) == 1 && grants[0].Permission == PermissionFullControl &&
+		granteeKey(grants[0].Grantee) == "id="+acle.Owner.ID {
+		return errACLUnchanged
+	}
+	grantee := newGranteeEncode(GranteeTypeCanonicalUser)
+	grantee.ID = acle.Owner.ID
+	grantee.DisplayName = acle.Owner.DisplayName
+	acle.AccessControlList.Grants = []GrantEncode{{Grantee: grantee, Permission: PermissionFullControl}}
+	return nil
+}
+
+// MakeObjectPrivate reduces the ACL of an object to a single FULL_CONTROL
+// grant to its current owner, as the private canned ACL would, keeping
+// the owner. Nothing is sent when the ACL is already private.
+func (c *Client) MakeObjectPrivate(ctx context.Context, bucketName, objectName string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	return c.updateACL(ctx, bucketName, objectName, makePrivate, UpdateACLOptions{})
+}
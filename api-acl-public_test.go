@@ -0,0 +1,213 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAddPublicReadGrant(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	initial := testACLXML(
+		testUserGrantXML("owner-id", "FULL_CONTROL"),
+		testUserGrantXML("auditor-id", "WRITE_ACP"),
+	)
+	stub.acls["/bucket/object?versionId="] = initial
+	stub.acls["/bucket/?versionId="] = initial
+
+	for i := 0; i < 2; i++ {
+		if err := clnt.AddPublicReadGrant(ctx, "bucket", "object"); err != nil {
+			t.Fatal(err)
+		}
+		if err := clnt.AddBucketPublicReadGrant(ctx, "bucket"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The second round finds the grant and sends nothing.
+	if n := stub.count(http.MethodPut); n != 2 {
+		t.Fatalf("expected 2 PUT requests, got %d", n)
+	}
+
+	for _, object := range []string{"object", ""} {
+		acld, err := clnt.getACLPolicy(ctx, "bucket", object, "", nil, ACLOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, g := range acld.AccessControlList.Grants {
+			keys = append(keys, grantKey(g.ToEncode()))
+		}
+		want := []string{
+			"id=owner-id FULL_CONTROL",
+			"id=auditor-id WRITE_ACP",
+			"uri=" + GroupAllUsers + " READ",
+		}
+		if len(keys) != len(want) {
+			t.Fatalf("object %q: expected grants %v, got %v", object, want, keys)
+		}
+		for i := range want {
+			if keys[i] != want[i] {
+				t.Errorf("object %q: expected grants %v, got %v", object, want, keys)
+				break
+			}
+		}
+	}
+}
+
+func TestIsObjectPublic(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	owner := testUserGrantXML("owner-id", "FULL_CONTROL")
+	stub.acls["/bucket/private?versionId="] = testACLXML(owner)
+	stub.acls["/bucket/public-read?versionId="] = testACLXML(owner, testGroupGrantXML(GroupAllUsers, "READ"))
+	stub.acls["/bucket/public-read-write?versionId="] = testACLXML(owner,
+		testGroupGrantXML(GroupAllUsers, "READ"), testGroupGrantXML(GroupAllUsers, "WRITE"))
+	stub.acls["/bucket/public-full-control?versionId="] = testACLXML(owner, testGroupGrantXML(GroupAllUsers, "FULL_CONTROL"))
+	stub.acls["/bucket/authenticated-read?versionId="] = testACLXML(owner, testGroupGrantXML(GroupAuthenticatedUsers, "READ"))
+
+	testCases := []struct {
+		object      string
+		public      bool
+		publicWrite bool
+	}{
+		{"private", false, false},
+		{"public-read", true, false},
+		{"public-read-write", true, true},
+		{"public-full-control", true, true},
+		{"authenticated-read", false, false},
+	}
+	for _, testCase := range testCases {
+		public, err := clnt.IsObjectPublic(ctx, "bucket", testCase.object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicWrite, err := clnt.IsObjectPublicWrite(ctx, "bucket", testCase.object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public != testCase.public || publicWrite != testCase.publicWrite {
+			t.Errorf("%s: expected public %v and public write %v, got %v and %v",
+				testCase.object, testCase.public, testCase.publicWrite, public, publicWrite)
+		}
+	}
+
+	if _, err := clnt.IsObjectPublic(ctx, "bucket", "missing"); ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Fatalf("expected NoSuchKey, got %v", err)
+	}
+}
+
+func TestRemovePublicAccess(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/public?versionId="] = testACLXML(
+		testUserGrantXML("owner-id", "FULL_CONTROL"),
+		testGroupGrantXML(GroupAllUsers, "FULL_CONTROL"),
+		testUserGrantXML("auditor-id", "READ_ACP"),
+		testGroupGrantXML(GroupAuthenticatedUsers, "READ"),
+		testGroupGrantXML(GroupLogDelivery, "WRITE"),
+	)
+	stub.acls["/bucket/private?versionId="] = testACLXML(testUserGrantXML("owner-id", "FULL_CONTROL"))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	if err := clnt.RemovePublicAccess(ctx, "bucket", "public"); err != nil {
+		t.Fatal(err)
+	}
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "public", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, g := range acld.AccessControlList.Grants {
+		keys = append(keys, grantKey(g.ToEncode()))
+	}
+	want := "id=owner-id FULL_CONTROL,id=auditor-id READ_ACP,uri=" + GroupLogDelivery + " WRITE"
+	if strings.Join(keys, ",") != want {
+		t.Fatalf("expected grants %s, got %v", want, keys)
+	}
+	if public, err := clnt.IsObjectPublic(ctx, "bucket", "public"); err != nil || public {
+		t.Fatalf("expected a non public object, got %v, %v", public, err)
+	}
+
+	// Nothing to remove, no PUT.
+	if err = clnt.RemovePublicAccess(ctx, "bucket", "private"); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodPut); n != 1 {
+		t.Fatalf("expected a single PUT request, got %d", n)
+	}
+}
+
+func TestMakeObjectPrivate(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/public-read-write?versionId="] = testACLXML(
+		testUserGrantXML("owner", "FULL_CONTROL"),
+		testGroupGrantXML(GroupAllUsers, "READ"),
+		testGroupGrantXML(GroupAllUsers, "WRITE"),
+	)
+	stub.acls["/bucket/custom?versionId="] = testACLXML(
+		testUserGrantXML("owner", "READ"),
+		testUserGrantXML("abc123", "WRITE_ACP"),
+		testGroupGrantXML(GroupLogDelivery, "WRITE"),
+	)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	for i, object := range []string{"public-read-write", "custom"} {
+		if err := clnt.MakeObjectPrivate(ctx, "bucket", object); err != nil {
+			t.Fatal(err)
+		}
+		if n := stub.count(http.MethodPut); n != i+1 {
+			t.Fatalf("%s: expected %d PUT requests, got %d", object, i+1, n)
+		}
+		acld, err := clnt.getACLPolicy(ctx, "bucket", object, "", nil, ACLOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acld.Owner.ID != "owner" {
+			t.Errorf("%s: expected the owner to be kept, got %+v", object, acld.Owner)
+		}
+		if canned := getCannedACL(acld, ""); canned != "private" {
+			t.Errorf("%s: expected a private ACL, got %q with %+v", object, canned, acld.AccessControlList.Grants)
+		}
+
+		// Already private, no PUT.
+		if err = clnt.MakeObjectPrivate(ctx, "bucket", object); err != nil {
+			t.Fatal(err)
+		}
+		if n := stub.count(http.MethodPut); n != i+1 {
+			t.Fatalf("%s: expected no PUT for a private object, got %d PUT requests", object, n)
+		}
+	}
+}
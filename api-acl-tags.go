@@ -0,0 +1,71 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// GetObjectACLAndTags returns the ACL, as GetObjectACL does, and the tags
+// of an object, fetching both concurrently. A failure of one half does
+// not discard the other: when only the tags cannot be read the ObjectInfo
+// is returned along with the error, and when only the ACL cannot be read
+// the tags are. Both results are nil only when both requests fail.
+func (c *Client) GetObjectACLAndTags(ctx context.Context, bucketName, objectName string) (*ObjectInfo, map[string]string, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, nil, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		wg             sync.WaitGroup
+		objInfo        *ObjectInfo
+		tagMap         map[string]string
+		aclErr, tagErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		objInfo, aclErr = c.GetObjectACL(ctx, bucketName, objectName)
+	}()
+	go func() {
+		defer wg.Done()
+		t, err := c.GetObjectTagging(ctx, bucketName, objectName, GetObjectTaggingOptions{})
+		if err != nil {
+			tagErr = err
+			return
+		}
+		tagMap = t.ToMap()
+	}()
+	wg.Wait()
+
+	switch {
+	case aclErr != nil:
+		return nil, tagMap, aclErr
+	case tagErr != nil:
+		return objInfo, nil, fmt.Errorf("object tags of %s/%s: %w", bucketName, objectName, tagErr)
+	}
+	return objInfo, tagMap, nil
+}
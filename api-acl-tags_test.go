@@ -0,0 +1,79 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetObjectACLAndTags(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", PermissionFullControl))
+	var failTags int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["tagging"]; ok {
+			if atomic.LoadInt32(&failTags) == 1 {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied", Message: "Access Denied."}))
+				return
+			}
+			w.Write([]byte(`<Tagging><TagSet><Tag><Key>team</Key><Value>storage</Value></Tag></TagSet></Tagging>`))
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	info, tagMap, err := clnt.GetObjectACLAndTags(ctx, "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Owner.ID != "owner" || len(info.Grant) != 1 {
+		t.Fatalf("unexpected ACL %+v", info)
+	}
+	if !reflect.DeepEqual(tagMap, map[string]string{"team": "storage"}) {
+		t.Fatalf("unexpected tags %v", tagMap)
+	}
+
+	// The ACL survives a tagging failure.
+	atomic.StoreInt32(&failTags, 1)
+	info, tagMap, err = clnt.GetObjectACLAndTags(ctx, "bucket", "object")
+	if ToErrorResponse(err).Code != "AccessDenied" {
+		t.Fatalf("expected AccessDenied, got %v", err)
+	}
+	if info == nil || info.Owner.ID != "owner" || info.Metadata.Get("X-Amz-Acl") != "private" {
+		t.Fatalf("expected the ACL along with the tagging error, got %+v", info)
+	}
+	if tagMap != nil {
+		t.Fatalf("expected no tags, got %v", tagMap)
+	}
+
+	// The tags survive an ACL failure.
+	atomic.StoreInt32(&failTags, 0)
+	info, tagMap, err = clnt.GetObjectACLAndTags(ctx, "bucket", "missing")
+	if ToErrorResponse(err).Code != "NoSuchKey" || info != nil || tagMap["team"] != "storage" {
+		t.Fatalf("expected NoSuchKey with the tags, got %+v, %v, %v", info, tagMap, err)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// GetBucketACLstring returns the ACL of a bucket as the raw XML
+// document sent by the server.
+func (c *Client) GetBucketACLstring(ctx context.Context, bucketName string) (string, error) {
+	return c.GetBucketACLstringWithOptions(ctx, bucketName, ACLOptions{})
+}
+
+// GetBucketACLstringWithOptions is GetBucketACLstring with options.
+func (c *Client) GetBucketACLstringWithOptions(ctx context.Context, bucketName string, opts ACLOptions) (string, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return "", err
+	}
+	return c.getACLString(ctx, bucketName, "", opts)
+}
+
+// GetBucketACL returns the decoded ACL of a bucket. It is served from
+// the bucket ACL cache when enabled, see Options.BucketACLCacheTTL.
+func (c *Client) GetBucketACL(ctx context.Context, bucketName string) (*AccessControlPolicyDecode, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, err
+	}
+	if c.bucketACLCache != nil {
+		return c.bucketACLCache.GetBucketACL(ctx, bucketName)
+	}
+	return c.getACLPolicy(ctx, bucketName, "", "", nil, ACLOptions{})
+}
+
+// GetBucketACLWithOptions is GetBucketACL with options. The bucket ACL
+// cache is not used.
+func (c *Client) GetBucketACLWithOptions(ctx context.Context, bucketName string, opts ACLOptions) (*AccessControlPolicyDecode, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	return c.getACLPolicy(ctx, bucketName, "", "", nil, opts)
+}
+
+// IsBucketPublic reports whether anyone can list the bucket, that is
+// whether its ACL grants READ or FULL_CONTROL to the AllUsers group.
+func (c *Client) IsBucketPublic(ctx context.Context, bucketName string) (bool, error) {
+	acld, err := c.GetBucketACL(ctx, bucketName)
+	if err != nil {
+		return false, err
+	}
+	return hasAllUsersGrant(acld, PermissionRead), nil
+}
+
+// ACLsEnabled probes whether the bucket supports ACLs by reading its
+// ACL. It returns false without error when the server replies with
+// ErrACLNotSupported, and the error of the probe otherwise. Servers
+// which only reject ACL updates, such as S3 with the
+// BucketOwnerEnforced object ownership, are reported as enabled. The
+// bucket ACL cache is not used.
+func (c *Client) ACLsEnabled(ctx context.Context, bucketName string) (bool, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return false, err
+	}
+	_, err := c.getACLPolicy(ctx, bucketName, "", "", nil, ACLOptions{})
+	if errors.Is(err, ErrACLNotSupported) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BucketACLInfo holds the classified ACL of a bucket.
+type BucketACLInfo struct {
+	Owner  Owner
+	Grants []GrantDecode
+
+	// CannedACL is the canned ACL matching the grants, if any.
+	CannedACL string
+
+	// GrantHeaders holds the grants in X-Amz-Grant-* header form when
+	// they do not match a canned ACL.
+	GrantHeaders map[string][]string
+}
+
+// GetBucketACLInfo returns the ACL of a bucket classified as a canned
+// ACL, or as X-Amz-Grant-* headers when no canned ACL matches.
+func (c *Client) GetBucketACLInfo(ctx context.Context, bucketName string) (*BucketACLInfo, error) {
+	acl, err := c.GetBucketACL(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	info := &BucketACLInfo{
+		Owner:     acl.Owner,
+		Grants:    acl.AccessControlList.Grants,
+		CannedACL: getCannedACL(acl, ""),
+	}
+	if info.CannedACL == "" {
+		info.GrantHeaders = getAmzGrantACL(acl, false)
+	}
+	return info, nil
+}
+
+// PutBucketACLstring sets the ACL of a bucket from a raw
+// AccessControlPolicy XML document, checked with ValidateACLXML.
+func (c *Client) PutBucketACLstring(ctx context.Context, bucketName, acl string) error {
+	return c.PutBucketACLstringWithOptions(ctx, bucketName, acl, ACLOptions{})
+}
+
+// PutBucketACLstringWithOptions is PutBucketACLstring with options.
+func (c *Client) PutBucketACLstringWithOptions(ctx context.Context, bucketName, acl string, opts ACLOptions) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := ValidateACLXML(acl); err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	return c.putACL(ctx, bucketName, "", "", nil, acl, opts)
+}
+
+// PutBucketAcl sets the ACL of a bucket.
+func (c *Client) PutBucketAcl(ctx context.Context, bucketName string, acle *AccessControlPolicyEncode) error {
+	return c.PutBucketACLWithOptions(ctx, bucketName, acle, PutBucketACLOptions{})
+}
+
+// PutBucketACLOptions holds options for PutBucketACLWithOptions.
+type PutBucketACLOptions struct {
+	ACLOptions
+
+	// IfNoExistingGrants fetches the current ACL first and fails with
+	// ErrExistingACLGrants, without setting the ACL, when it holds any
+	// grant besides the FULL_CONTROL grant of the bucket owner. It lets
+	// provisioning set the ACL of a new bucket without clobbering later
+	// edits. The check and the update are not atomic.
+	IfNoExistingGrants bool
+}
+
+// PutBucketACLWithOptions sets the ACL of a bucket with options.
+func (c *Client) PutBucketACLWithOptions(ctx context.Context, bucketName string, acle *AccessControlPolicyEncode, opts PutBucketACLOptions) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if acle == nil {
+		return errInvalidArgument("ACL policy cannot be nil.")
+	}
+	if err := acle.validate(); err != nil {
+		return err
+	}
+	aclBytes, err := opts.marshalPolicy(acle)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	if opts.IfNoExistingGrants {
+		current, err := c.getACLPolicy(ctx, bucketName, "", "", nil, opts.ACLOptions)
+		if err != nil {
+			return err
+		}
+		if hasNonOwnerGrants(current) {
+			return ErrExistingACLGrants
+		}
+	}
+	return c.putACL(ctx, bucketName, "", "", nil, string(aclBytes), opts.ACLOptions)
+}
+
+// hasNonOwnerGrants reports whether the policy holds any grant besides
+// the FULL_CONTROL grant of its owner, the ACL of a new bucket.
+func hasNonOwnerGrants(acld *AccessControlPolicyDecode) bool {
+	for _, g := range acld.AccessControlList.Grants {
+		if g.Grantee.ID != acld.Owner.ID || g.Grantee.URI != "" || g.Grantee.Email != "" || g.Permission != PermissionFullControl {
+			return true
+		}
+	}
+	return false
+}
+
+// PutBucketACLCanned sets a canned ACL on a bucket through the
+// x-amz-acl header, without an XML body. On top of the object canned
+// ACLs, buckets accept log-delivery-write.
+func (c *Client) PutBucketACLCanned(ctx context.Context, bucketName, cannedACL string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if !objectCannedACLs[cannedACL] && cannedACL != "log-delivery-write" {
+		return errInvalidArgument("Unsupported canned ACL " + cannedACL + ".")
+	}
+	customHeader := make(http.Header)
+	customHeader.Set("x-amz-acl", cannedACL)
+	return c.putACL(ctx, bucketName, "", "", customHeader, "", ACLOptions{})
+}
@@ -0,0 +1,342 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBucketACLXML = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner>
+    <ID>75aa57f09aa0c8caeab4f8c24e99d10f8e7faeebf76c078efc7c6caea54ba06a</ID>
+    <DisplayName>mtd@amazon.com</DisplayName>
+  </Owner>
+  <AccessControlList>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser">
+        <ID>75aa57f09aa0c8caeab4f8c24e99d10f8e7faeebf76c078efc7c6caea54ba06a</ID>
+        <DisplayName>mtd@amazon.com</DisplayName>
+      </Grantee>
+      <Permission>FULL_CONTROL</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group">
+        <URI>http://acs.amazonaws.com/groups/global/AllUsers</URI>
+      </Grantee>
+      <Permission>READ</Permission>
+    </Grant>
+  </AccessControlList>
+</AccessControlPolicy>`
+
+// newACLTestClient returns a client talking to the given test server.
+func newACLTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	clnt, err := New(srv.Listener.Addr().String(), &Options{
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clnt
+}
+
+func TestGetBucketACL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["acl"]; !ok || r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Write([]byte(testBucketACLXML))
+	}))
+	defer srv.Close()
+
+	acl, err := newACLTestClient(t, srv).GetBucketACL(context.Background(), "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acl.Owner.ID != "75aa57f09aa0c8caeab4f8c24e99d10f8e7faeebf76c078efc7c6caea54ba06a" || acl.Owner.DisplayName != "mtd@amazon.com" {
+		t.Fatalf("unexpected owner %+v", acl.Owner)
+	}
+	grants := acl.AccessControlList.Grants
+	if len(grants) != 2 {
+		t.Fatalf("expected 2 grants, got %d", len(grants))
+	}
+	if grants[0].Grantee.Type != "CanonicalUser" || grants[0].Permission != "FULL_CONTROL" {
+		t.Errorf("unexpected first grant %+v", grants[0])
+	}
+	if grants[1].Grantee.Type != "Group" || grants[1].Grantee.URI != "http://acs.amazonaws.com/groups/global/AllUsers" || grants[1].Permission != "READ" {
+		t.Errorf("unexpected second grant %+v", grants[1])
+	}
+
+	s, err := newACLTestClient(t, srv).GetBucketACLstring(context.Background(), "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != testBucketACLXML {
+		t.Errorf("unexpected raw ACL %q", s)
+	}
+}
+
+func TestGetBucketACLError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(encodeResponse(ErrorResponse{Code: "NoSuchBucket", Message: "The specified bucket does not exist."}))
+	}))
+	defer srv.Close()
+
+	_, err := newACLTestClient(t, srv).GetBucketACL(context.Background(), "bucket")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if errResp := ToErrorResponse(err); errResp.Code != "NoSuchBucket" || errResp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+func TestPutBucketACLCanned(t *testing.T) {
+	var cannedACLs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cannedACLs = append(cannedACLs, r.Header.Get("x-amz-acl"))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	for _, canned := range []string{"private", "log-delivery-write"} {
+		if err := clnt.PutBucketACLCanned(context.Background(), "bucket", canned); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := clnt.PutBucketACLCanned(context.Background(), "bucket", "public")
+	if errResp := ToErrorResponse(err); errResp.Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if strings.Join(cannedACLs, ",") != "private,log-delivery-write" {
+		t.Fatalf("unexpected canned ACLs sent %v", cannedACLs)
+	}
+}
+
+func TestGetBucketACLInfo(t *testing.T) {
+	testCases := []struct {
+		xml          string
+		canned       string
+		grantHeaders map[string][]string
+	}{
+		{testBucketACLXML, "public-read", nil},
+		{
+			testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("abc123", "WRITE")),
+			"",
+			map[string][]string{
+				"X-Amz-Grant-Full-Control": {`id="owner"`},
+				"X-Amz-Grant-Write":        {`id="abc123"`},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		var requests int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Write([]byte(testCase.xml))
+		}))
+
+		info, err := newACLTestClient(t, srv).GetBucketACLInfo(context.Background(), "bucket")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if requests != 1 {
+			t.Errorf("Test %d: expected a single request, got %d", i+1, requests)
+		}
+		if info.CannedACL != testCase.canned {
+			t.Errorf("Test %d: expected canned ACL %q, got %q", i+1, testCase.canned, info.CannedACL)
+		}
+		if !reflect.DeepEqual(info.GrantHeaders, testCase.grantHeaders) {
+			t.Errorf("Test %d: expected grant headers %v, got %v", i+1, testCase.grantHeaders, info.GrantHeaders)
+		}
+		if info.Owner.ID == "" || len(info.Grants) != 2 {
+			t.Errorf("Test %d: unexpected owner %+v or grants %+v", i+1, info.Owner, info.Grants)
+		}
+	}
+}
+
+func TestIsBucketPublicCache(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/?versionId="] = testBucketACLXML
+	stub.acls["/private/?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt, err := New(srv.Listener.Addr().String(), &Options{
+		Region:            "us-east-1",
+		BucketACLCacheTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clnt.bucketACLCache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	isPublic := func(bucketName string, want bool) {
+		t.Helper()
+		public, err := clnt.IsBucketPublic(ctx, bucketName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public != want {
+			t.Fatalf("%s: expected public %v, got %v", bucketName, want, public)
+		}
+	}
+
+	// Cache hit.
+	isPublic("bucket", true)
+	isPublic("bucket", true)
+	isPublic("private", false)
+	if n := stub.count(http.MethodGet); n != 2 {
+		t.Fatalf("expected 2 GET requests, got %d", n)
+	}
+
+	// TTL expiry.
+	now = now.Add(time.Minute)
+	isPublic("bucket", true)
+	if n := stub.count(http.MethodGet); n != 3 {
+		t.Fatalf("expected the expired ACL to be fetched again, got %d GET requests", n)
+	}
+
+	// Invalidation on write.
+	if err = clnt.PutBucketACLCanned(ctx, "bucket", "private"); err != nil {
+		t.Fatal(err)
+	}
+	stub.mu.Lock()
+	stub.acls["/bucket/?versionId="] = stub.acls["/private/?versionId="]
+	stub.mu.Unlock()
+	isPublic("bucket", false)
+	if n := stub.count(http.MethodGet); n != 4 {
+		t.Fatalf("expected 4 GET requests, got %d", n)
+	}
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	if err = clnt.PutBucketAcl(ctx, "bucket", acle); err != nil {
+		t.Fatal(err)
+	}
+	isPublic("bucket", false)
+	isPublic("bucket", false)
+	if n := stub.count(http.MethodGet); n != 5 {
+		t.Fatalf("expected 5 GET requests, got %d", n)
+	}
+}
+
+func TestIsBucketPublicNoCache(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/?versionId="] = testBucketACLXML
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	for i := 0; i < 2; i++ {
+		public, err := clnt.IsBucketPublic(context.Background(), "bucket")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !public {
+			t.Fatal("expected a public bucket")
+		}
+	}
+	if n := stub.count(http.MethodGet); n != 2 {
+		t.Fatalf("expected 2 GET requests without cache, got %d", n)
+	}
+}
+
+func TestPutBucketACLIfNoExistingGrants(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("abc123", "READ"))
+	stub.acls["/fresh/?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acle := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	opts := PutBucketACLOptions{IfNoExistingGrants: true}
+
+	err := clnt.PutBucketACLWithOptions(ctx, "bucket", acle, opts)
+	if !errors.Is(err, ErrExistingACLGrants) {
+		t.Fatalf("expected ErrExistingACLGrants, got %v", err)
+	}
+	if n := stub.count(http.MethodPut); n != 0 {
+		t.Fatalf("expected the guarded PUT to be skipped, got %d PUT requests", n)
+	}
+
+	if err = clnt.PutBucketACLWithOptions(ctx, "fresh", acle, opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodPut); n != 1 {
+		t.Fatalf("expected a single PUT request, got %d", n)
+	}
+
+	// The check is off by default.
+	if err = clnt.PutBucketACLWithOptions(ctx, "bucket", acle, PutBucketACLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodGet); n != 2 {
+		t.Fatalf("expected 2 GET requests, got %d", n)
+	}
+}
+
+func TestACLsEnabled(t *testing.T) {
+	const notSupported = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessControlListNotSupported</Code><Message>The bucket does not allow ACLs</Message><BucketName>disabled</BucketName></Error>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/enabled/":
+			w.Write([]byte(testBucketACLXML))
+		case "/disabled/":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(notSupported))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	if enabled, err := clnt.ACLsEnabled(ctx, "enabled"); err != nil || !enabled {
+		t.Fatalf("expected ACLs enabled, got %v, %v", enabled, err)
+	}
+	if enabled, err := clnt.ACLsEnabled(ctx, "disabled"); err != nil || enabled {
+		t.Fatalf("expected ACLs disabled, got %v, %v", enabled, err)
+	}
+	if _, err := clnt.ACLsEnabled(ctx, "denied"); ToErrorResponse(err).Code != "AccessDenied" {
+		t.Fatalf("expected AccessDenied, got %v", err)
+	}
+
+	err := clnt.PutBucketACLCanned(ctx, "disabled", "private")
+	if !errors.Is(err, ErrACLNotSupported) {
+		t.Fatalf("expected ErrACLNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// CopyACLOptions holds options for CopyObjectACLWithOptions.
+type CopyACLOptions struct {
+	// NewOwner, when set, replaces the owner of the source ACL, e.g.
+	// when the destination bucket belongs to another account.
+	NewOwner *Owner
+
+	// DropOwnerGrants removes the grants held by the canonical user
+	// owning the source object, whose ID may be unknown to the
+	// destination account.
+	DropOwnerGrants bool
+
+	// Force writes the destination ACL even when it already matches.
+	Force bool
+}
+
+// CopyObjectACL copies the ACL of the source object, owner and grants
+// included, to the destination object. It reports whether the ACL was
+// written, see CopyObjectACLWithOptions.
+func (c *Client) CopyObjectACL(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string) (copied bool, err error) {
+	return c.CopyObjectACLWithOptions(ctx, srcBucket, srcObject, dstBucket, dstObject, CopyACLOptions{})
+}
+
+// CopyObjectACLWithOptions copies the ACL of the source object to the
+// destination object, remapping its owner according to opts. Unless
+// opts.Force is set, nothing is written when the destination ACL
+// already has the same owner and the same set of grants, see Equal. It
+// reports whether the ACL was written.
+func (c *Client) CopyObjectACLWithOptions(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string, opts CopyACLOptions) (copied bool, err error) {
+	// Input validation.
+	if err = s3utils.CheckValidBucketName(srcBucket); err != nil {
+		return false, err
+	}
+	if err = s3utils.CheckValidObjectName(srcObject); err != nil {
+		return false, err
+	}
+	if err = s3utils.CheckValidBucketName(dstBucket); err != nil {
+		return false, err
+	}
+	if err = s3utils.CheckValidObjectName(dstObject); err != nil {
+		return false, err
+	}
+	if opts.NewOwner != nil && opts.NewOwner.ID == "" {
+		return false, errInvalidArgument("New owner ID cannot be empty.")
+	}
+
+	acld, err := c.getACLPolicy(ctx, srcBucket, srcObject, "", nil, ACLOptions{})
+	if err != nil {
+		return false, err
+	}
+	acle := policyDecodeToEncode(acld)
+	if opts.DropOwnerGrants && acld.Owner.ID != "" {
+		acle.RemoveGranteeAll(acld.Owner.ID)
+	}
+	if opts.NewOwner != nil {
+		acle.Owner = Owner{ID: opts.NewOwner.ID, DisplayName: opts.NewOwner.DisplayName}
+	}
+	if !opts.Force {
+		// A missing destination is left to the PUT to report.
+		current, err := c.getACLPolicy(ctx, dstBucket, dstObject, "", nil, ACLOptions{})
+		if err != nil && !errors.Is(err, ErrNoSuchKey) {
+			return false, err
+		}
+		if err == nil && current.Equal(policyEncodeToDecode(acle)) {
+			return false, nil
+		}
+	}
+	if err = c.PutObjectAcl(ctx, dstBucket, dstObject, acle); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,170 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCopyObjectACL(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	src := NewACLBuilder(Owner{ID: "owner", DisplayName: "Owner"}).
+		GrantCanonicalUser("owner", "FULL_CONTROL").
+		GrantEmail("user@example.com", "READ").
+		GrantGroup("http://acs.amazonaws.com/groups/global/AllUsers", "READ").
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "src-bucket", "src-object", src); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := clnt.CopyObjectACL(ctx, "src-bucket", "src-object", "dst-bucket", "dst-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied {
+		t.Fatal("expected the ACL to be copied")
+	}
+
+	srcACL, err := clnt.GetObjectACLstring(ctx, "src-bucket", "src-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstACL, err := clnt.GetObjectACLstring(ctx, "dst-bucket", "dst-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srcACL != dstACL {
+		t.Fatalf("destination ACL\n%s\ndoes not match source ACL\n%s", dstACL, srcACL)
+	}
+
+	dst, err := clnt.getACLPolicy(ctx, "dst-bucket", "dst-object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policyDecodeToEncode(dst), src) {
+		t.Fatalf("expected %+v, got %+v", src, policyDecodeToEncode(dst))
+	}
+}
+
+func TestCopyObjectACLRemapOwner(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	src := NewACLBuilder(Owner{ID: "owner", DisplayName: "Owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantCanonicalUser("alice", PermissionRead).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "src-bucket", "src-object", src); err != nil {
+		t.Fatal(err)
+	}
+	newOwner := &Owner{ID: "new-owner", DisplayName: "New Owner"}
+
+	testCases := []struct {
+		opts   CopyACLOptions
+		owner  string
+		grants []string
+	}{
+		{CopyACLOptions{NewOwner: newOwner}, "new-owner", []string{"id=owner FULL_CONTROL", "id=alice READ", "uri=" + GroupAllUsers + " READ"}},
+		{CopyACLOptions{NewOwner: newOwner, DropOwnerGrants: true}, "new-owner", []string{"id=alice READ", "uri=" + GroupAllUsers + " READ"}},
+		{CopyACLOptions{DropOwnerGrants: true}, "owner", []string{"id=alice READ", "uri=" + GroupAllUsers + " READ"}},
+	}
+	for i, testCase := range testCases {
+		if _, err := clnt.CopyObjectACLWithOptions(ctx, "src-bucket", "src-object", "dst-bucket", "dst-object", testCase.opts); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		dst, err := clnt.getACLPolicy(ctx, "dst-bucket", "dst-object", "", nil, ACLOptions{})
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if dst.Owner.ID != testCase.owner {
+			t.Errorf("Test %d: expected owner %s, got %+v", i+1, testCase.owner, dst.Owner)
+		}
+		var grants []string
+		for _, g := range dst.AccessControlList.Grants {
+			grants = append(grants, grantKey(g.ToEncode()))
+		}
+		if !reflect.DeepEqual(grants, testCase.grants) {
+			t.Errorf("Test %d: expected grants %v, got %v", i+1, testCase.grants, grants)
+		}
+	}
+
+	_, err := clnt.CopyObjectACLWithOptions(ctx, "src-bucket", "src-object", "dst-bucket", "dst-object", CopyACLOptions{NewOwner: &Owner{}})
+	if ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCopyObjectACLUnchanged(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acl := NewACLBuilder(Owner{ID: "owner", DisplayName: "Owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "src-bucket", "src-object", acl); err != nil {
+		t.Fatal(err)
+	}
+	// Same grants in a different order.
+	dst := NewACLBuilder(Owner{ID: "owner", DisplayName: "Owner"}).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "dst-bucket", "dst-object", dst); err != nil {
+		t.Fatal(err)
+	}
+	puts := stub.count(http.MethodPut)
+
+	copied, err := clnt.CopyObjectACL(ctx, "src-bucket", "src-object", "dst-bucket", "dst-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied {
+		t.Error("expected no copy when the destination already matches")
+	}
+	if n := stub.count(http.MethodPut) - puts; n != 0 {
+		t.Fatalf("expected no PUT, got %d", n)
+	}
+
+	copied, err = clnt.CopyObjectACLWithOptions(ctx, "src-bucket", "src-object", "dst-bucket", "dst-object", CopyACLOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied {
+		t.Error("expected Force to copy the ACL")
+	}
+	if n := stub.count(http.MethodPut) - puts; n != 1 {
+		t.Fatalf("expected 1 PUT with Force, got %d", n)
+	}
+}
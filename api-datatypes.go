@@ -66,8 +66,8 @@ func (m *StringMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 // Owner name.
 type Owner struct {
 	XMLName     xml.Name `xml:"Owner" json:"owner"`
-	DisplayName string   `xml:"ID" json:"name"`
-	ID          string   `xml:"DisplayName" json:"id"`
+	ID          string   `xml:"ID" json:"id"`
+	DisplayName string   `xml:"DisplayName" json:"name"`
 }
 
 // UploadInfo contains information about the
@@ -133,6 +133,10 @@ type ObjectInfo struct {
 	// ACL grant.
 	Grant []Grant
 
+	// ACL grants with a permission unknown to S3, see
+	// GetObjectACLOptions.UnknownPermission.
+	UnknownGrants []GrantDecode `json:"unknownGrants,omitempty"`
+
 	// The class of storage used to store the object.
 	StorageClass string `json:"storageClass"`
 
@@ -20,6 +20,7 @@ package minio
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -80,6 +81,11 @@ func ToErrorResponse(err error) ErrorResponse {
 	case ErrorResponse:
 		return err
 	default:
+		// Look for a wrapped ErrorResponse, e.g. in an ACLRequestError.
+		var errResp ErrorResponse
+		if errors.As(err, &errResp) {
+			return errResp
+		}
 		return ErrorResponse{}
 	}
 }
@@ -96,6 +102,13 @@ func (e ErrorResponse) Error() string {
 	return e.Message
 }
 
+// Is reports whether the error response matches target, one of the
+// sentinel errors mapped to an S3 error code such as ErrMalformedACL.
+func (e ErrorResponse) Is(target error) bool {
+	code, ok := errorCodeSentinels[target]
+	return ok && e.Code == code
+}
+
 // Common string for errors to report issue location in unexpected
 // cases.
 const (
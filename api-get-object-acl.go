@@ -18,81 +18,654 @@
 package minio
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
 )
 
-// Grantee represents the person being granted permissions.
-type Grantee struct {
-	XMLName     xml.Name `xml:"Grantee"`
-	ID          string   `xml:"ID"`
-	DisplayName string   `xml:"DisplayName"`
-	URI         string   `xml:"URI"`
-}
+// Grantee represents the person being granted permissions. It is kept
+// as an alias of GranteeDecode for compatibility.
+type Grantee = GranteeDecode
 
-// Grant holds grant information
-type Grant struct {
-	XMLName    xml.Name `xml:"Grant"`
-	Grantee    Grantee
-	Permission string `xml:"Permission"`
-}
+// Grant holds grant information, as returned in ObjectInfo.Grant. It is
+// kept as an alias of GrantDecode for compatibility.
+type Grant = GrantDecode
 
 // AccessControlList contains the set of grantees and the permissions assigned to each grantee.
+//
+// Deprecated: AccessControlList is not used by the client, decoded ACLs
+// are returned as AccessControlListDecode.
 type AccessControlList struct {
 	XMLName    xml.Name `xml:"AccessControlList"`
 	Grant      []Grant
 	Permission string `xml:"Permission"`
 }
 
-type accessControlPolicy struct {
-	XMLName           xml.Name `xml:"AccessControlPolicy"`
-	Owner             Owner
-	AccessControlList AccessControlList
+// GranteeDecode is the grantee of an ACL grant as returned by the server.
+// The xsi:type attribute of the Grantee element is decoded into XMLXSI
+// and copied into Type.
+type GranteeDecode struct {
+	XMLName     xml.Name `xml:"Grantee" json:"-"`
+	XMLNS       string   `xml:"xmlns xsi,attr,omitempty" json:"-"`
+	XMLXSI      string   `xml:"http://www.w3.org/2001/XMLSchema-instance type,attr,omitempty" json:"-"`
+	Type        string   `xml:"-" json:"type"`
+	ID          string   `xml:"ID,omitempty" json:"id,omitempty"`
+	DisplayName string   `xml:"DisplayName,omitempty" json:"displayName,omitempty"`
+	URI         string   `xml:"URI,omitempty" json:"uri,omitempty"`
+	Email       string   `xml:"EmailAddress,omitempty" json:"email,omitempty"`
 }
 
-// GetObjectACL get object ACLs
-func (c *Client) GetObjectACL(ctx context.Context, bucketName, objectName string) (*ObjectInfo, error) {
-	resp, err := c.executeMethod(ctx, http.MethodGet, requestMetadata{
-		bucketName: bucketName,
-		objectName: objectName,
-		queryValues: url.Values{
-			"acl": []string{""},
-		},
-	})
+// GrantDecode holds a single decoded ACL grant.
+type GrantDecode struct {
+	XMLName    xml.Name      `xml:"Grant" json:"-"`
+	Grantee    GranteeDecode `xml:"Grantee" json:"grantee"`
+	Permission string        `xml:"Permission" json:"permission"`
+}
+
+// AccessControlListDecode contains the decoded grants of an ACL.
+type AccessControlListDecode struct {
+	XMLName xml.Name      `xml:"AccessControlList" json:"-"`
+	Grants  []GrantDecode `xml:"Grant" json:"grants"`
+}
+
+// ACLExtension is an element of an AccessControlPolicy document that is
+// not modeled by this package, e.g. a vendor extension added by a
+// gateway. It is kept verbatim so that it survives a read-modify-write.
+type ACLExtension struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+// MarshalXML writes the extension back with the namespace declarations
+// it was decoded with. The decoder keeps these declarations in Attrs, so
+// the default encoding would declare the element namespace twice and
+// mangle the prefixed declarations.
+func (ext ACLExtension) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: ext.XMLName.Local}}
+	prefixes := make(map[string]string)
+	hasDefault := false
+	for _, attr := range ext.Attrs {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			hasDefault = true
+		case attr.Name.Space == "xmlns":
+			prefixes[attr.Value] = attr.Name.Local
+		}
+	}
+	if ext.XMLName.Space != "" && !hasDefault {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: ext.XMLName.Space})
+	}
+	for _, attr := range ext.Attrs {
+		switch {
+		case attr.Name.Space == "xmlns":
+			attr.Name = xml.Name{Local: "xmlns:" + attr.Name.Local}
+		case attr.Name.Space != "":
+			if prefix, ok := prefixes[attr.Name.Space]; ok {
+				attr.Name = xml.Name{Local: prefix + ":" + attr.Name.Local}
+			}
+		}
+		start.Attr = append(start.Attr, attr)
+	}
+	return e.EncodeElement(struct {
+		InnerXML string `xml:",innerxml"`
+	}{ext.InnerXML}, start)
+}
+
+// AccessControlPolicyDecode is the decoded form of the
+// AccessControlPolicy document returned by GET ?acl.
+//
+// Extensions holds the unknown children of the AccessControlPolicy
+// element. Unknown elements nested deeper, e.g. inside a Grant, are
+// dropped.
+type AccessControlPolicyDecode struct {
+	XMLName           xml.Name                `xml:"AccessControlPolicy" json:"-"`
+	Owner             Owner                   `xml:"Owner" json:"owner"`
+	AccessControlList AccessControlListDecode `xml:"AccessControlList" json:"accessControlList"`
+	Extensions        []ACLExtension          `xml:",any" json:"extensions,omitempty"`
+}
+
+// MaxACLResponseSize is the default maximum size in bytes of an ACL
+// response body, see ACLOptions.MaxResponseSize. Larger responses fail
+// with ErrACLResponseTooLarge rather than being read into memory.
+const MaxACLResponseSize = 4 << 20
+
+// maxPooledACLBuffer is the capacity above which a buffer is not
+// returned to aclBufferPool, so that an occasional large ACL does not
+// stay pinned in memory.
+const maxPooledACLBuffer = 64 << 10
+
+// aclBufferPool holds the buffers ACL response bodies are read into,
+// bulk audits read thousands of small bodies.
+var aclBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// putACLBuffer returns a buffer obtained from readACLBody to the pool.
+// Its bytes must not be used afterwards.
+func putACLBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledACLBuffer {
+		return
+	}
+	buf.Reset()
+	aclBufferPool.Put(buf)
+}
+
+// readACLBody reads an ACL response body of at most maxSize bytes into
+// a pooled buffer, to be released with putACLBuffer.
+func readACLBody(body io.Reader, maxSize int64) (*bytes.Buffer, error) {
+	buf := aclBufferPool.Get().(*bytes.Buffer)
+	_, err := buf.ReadFrom(io.LimitReader(body, maxSize+1))
+	if err == io.ErrUnexpectedEOF {
+		putACLBuffer(buf)
+		return nil, fmt.Errorf("%w: %v", ErrTruncatedACLResponse, err)
+	}
 	if err != nil {
+		putACLBuffer(buf)
 		return nil, err
 	}
-	defer closeResponse(resp)
+	if int64(buf.Len()) > maxSize {
+		putACLBuffer(buf)
+		return nil, ErrACLResponseTooLarge
+	}
+	return buf, nil
+}
+
+// isTruncatedXML reports whether err is the error of an XML decoder
+// reaching the end of its input in the middle of a document.
+func isTruncatedXML(err error) bool {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	var syntaxErr *xml.SyntaxError
+	return errors.As(err, &syntaxErr) && syntaxErr.Msg == "unexpected EOF"
+}
+
+// decodeACLBody decodes an AccessControlPolicy document into v. A body
+// ending mid-document fails with ErrTruncatedACLResponse, a document
+// with another root element fails with ErrNotAnACLResponse.
+func decodeACLBody(body []byte, v interface{}) error {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if isTruncatedXML(err) {
+				return fmt.Errorf("%w: no AccessControlPolicy element in %d bytes", ErrTruncatedACLResponse, len(body))
+			}
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "AccessControlPolicy" {
+			return fmt.Errorf("%w: unexpected root element <%s>, expected <AccessControlPolicy>", ErrNotAnACLResponse, start.Name.Local)
+		}
+		if err = d.DecodeElement(v, &start); err != nil {
+			if isTruncatedXML(err) {
+				return fmt.Errorf("%w: %v", ErrTruncatedACLResponse, err)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// aclPolicyXML decodes an AccessControlPolicy document into policy,
+// recording every Owner element: decoding into the struct would keep
+// the last of repeated elements, which would silently attribute the ACL
+// to the wrong owner.
+type aclPolicyXML struct {
+	policy *AccessControlPolicyDecode
+	owners []Owner
+}
+
+// UnmarshalXML decodes the children of the AccessControlPolicy element
+// as the AccessControlPolicyDecode struct tags would.
+func (p *aclPolicyXML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	p.policy.XMLName = start.Name
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Owner":
+				var o Owner
+				if err = d.DecodeElement(&o, &t); err != nil {
+					return err
+				}
+				p.owners = append(p.owners, o)
+			case "AccessControlList":
+				err = d.DecodeElement(&p.policy.AccessControlList, &t)
+			default:
+				var ext ACLExtension
+				if err = d.DecodeElement(&ext, &t); err == nil {
+					p.policy.Extensions = append(p.policy.Extensions, ext)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
 
+// getACL executes GET ?acl on a bucket, or on an object when objectName
+// is non-empty, and returns the body and the headers of the response for
+// a successful request. The body must be released with putACLBuffer.
+func (c *Client) getACL(ctx context.Context, bucketName, objectName, versionID string, customHeader http.Header, opts ACLOptions) (body *bytes.Buffer, header http.Header, err error) {
+	customHeader, err = opts.setHeaders(customHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	urlValues := make(url.Values)
+	urlValues.Set("acl", "")
+	if versionID != "" {
+		urlValues.Set("versionId", versionID)
+	}
+
+	reqMetadata := requestMetadata{
+		bucketName:       bucketName,
+		objectName:       objectName,
+		queryValues:      urlValues,
+		customHeader:     customHeader,
+		contentSHA256Hex: emptySHA256Hex,
+	}
+	opts.setRetry(&reqMetadata)
+
+	start := time.Now()
+	resp, err := c.executeMethod(ctx, http.MethodGet, reqMetadata)
+	defer closeResponse(resp)
+	if opts.OnComplete != nil {
+		defer func() {
+			var n int64
+			if body != nil {
+				n = int64(body.Len())
+			}
+			opts.onComplete(http.MethodGet, reqMetadata, resp, n, start, err)
+		}()
+	}
+	if err != nil {
+		return nil, nil, newACLRequestError(http.MethodGet, reqMetadata, err)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, httpRespToErrorResponse(resp, bucketName, objectName)
+		return nil, nil, newACLRequestError(http.MethodGet, reqMetadata, httpRespToErrorResponse(resp, bucketName, objectName))
+	}
+	if body, err = readACLBody(resp.Body, opts.maxResponseSize()); err != nil {
+		return nil, nil, err
+	}
+	if err = checkACLResponse(body.Bytes(), resp.Header.Get("Content-Type")); err != nil {
+		putACLBuffer(body)
+		body = nil
+		return nil, nil, err
 	}
+	return body, resp.Header, nil
+}
+
+// checkACLResponse fails with ErrNotAnACLResponse when an ACL response
+// body is not an XML document, typically the body of the object itself
+// because a proxy stripped the acl query parameter. JSON responses, see
+// GetObjectACLOptions.AcceptJSON, and empty bodies are left to the
+// decoder.
+func checkACLResponse(body []byte, contentType string) error {
+	if isJSONContentType(contentType) {
+		return nil
+	}
+	body = bytes.TrimLeft(body, " \t\r\n\ufeff")
+	if len(body) == 0 || body[0] == '<' {
+		return nil
+	}
+	if contentType == "" {
+		contentType = "untyped"
+	}
+	return fmt.Errorf("%w: %s response does not start with an XML element", ErrNotAnACLResponse, contentType)
+}
+
+// amzRequestPayer is the header acknowledging requester-pays charges.
+const amzRequestPayer = "X-Amz-Request-Payer"
 
-	res := &accessControlPolicy{}
+// requestPayerHeader adds the requester-pays header to customHeader when
+// requestPayer is set, allocating it if needed.
+func requestPayerHeader(customHeader http.Header, requestPayer bool) http.Header {
+	if !requestPayer {
+		return customHeader
+	}
+	if customHeader == nil {
+		customHeader = make(http.Header)
+	}
+	customHeader.Set(amzRequestPayer, "requester")
+	return customHeader
+}
 
-	if err := xmlDecoder(resp.Body, res); err != nil {
+// getACLPolicy fetches and decodes the ACL of a bucket or an object.
+func (c *Client) getACLPolicy(ctx context.Context, bucketName, objectName, versionID string, customHeader http.Header, opts ACLOptions) (*AccessControlPolicyDecode, error) {
+	res, _, err := c.getACLPolicyHeader(ctx, bucketName, objectName, versionID, customHeader, opts)
+	return res, err
+}
+
+// getACLPolicyHeader fetches and decodes the ACL of a bucket or an
+// object, also returning the response headers, e.g. the ETag or the
+// version ID.
+func (c *Client) getACLPolicyHeader(ctx context.Context, bucketName, objectName, versionID string, customHeader http.Header, opts ACLOptions) (*AccessControlPolicyDecode, http.Header, error) {
+	buf, header, err := c.getACL(ctx, bucketName, objectName, versionID, customHeader, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer putACLBuffer(buf)
+	res := &AccessControlPolicyDecode{}
+	if isJSONContentType(header.Get("Content-Type")) {
+		if err = json.Unmarshal(buf.Bytes(), res); err != nil {
+			return nil, nil, err
+		}
+		for i := range res.AccessControlList.Grants {
+			g := &res.AccessControlList.Grants[i]
+			g.Grantee.XMLXSI = g.Grantee.Type
+		}
+	} else if err = c.decodeACLPolicyXML(buf.Bytes(), res, bucketName, objectName, opts); err != nil {
+		return nil, nil, err
+	}
+	if res.AccessControlList.Grants, err = checkEmptyPermissions(res.AccessControlList.Grants, opts.SkipEmptyPermission); err != nil {
+		return nil, nil, fmt.Errorf("%w in the ACL of %s/%s", err, bucketName, objectName)
+	}
+	for i := range res.AccessControlList.Grants {
+		g := &res.AccessControlList.Grants[i]
+		// Some servers omit xsi:type, infer it from the identity sent.
+		if g.Grantee.Type == "" && (g.Grantee.ID != "" || g.Grantee.URI != "" || g.Grantee.Email != "") {
+			g.Grantee.Type = granteeType(GranteeEncode{URI: g.Grantee.URI, Email: g.Grantee.Email})
+		}
+	}
+	return res, header, nil
+}
+
+// isJSONContentType reports whether contentType is a JSON media type,
+// as returned by gateways asked for JSON ACLs.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeACLPolicyXML decodes an AccessControlPolicy XML document into
+// res, setting the type of every grantee from its xsi:type.
+func (c *Client) decodeACLPolicyXML(body []byte, res *AccessControlPolicyDecode, bucketName, objectName string, opts ACLOptions) error {
+	p := aclPolicyXML{policy: res}
+	if err := decodeACLBody(body, &p); err != nil {
+		return err
+	}
+	switch n := len(p.owners); {
+	case n == 1:
+		res.Owner = p.owners[0]
+	case n > 1:
+		if !opts.AllowMultipleOwners {
+			return fmt.Errorf("%w: %d Owner elements in the ACL of %s/%s", ErrMultipleACLOwners, n, bucketName, objectName)
+		}
+		if c.isTraceEnabled {
+			fmt.Fprintf(c.traceOutput, "%d Owner elements in the ACL of %s/%s, keeping the first one\n", n, bucketName, objectName)
+		}
+		res.Owner = Owner{ID: p.owners[0].ID, DisplayName: p.owners[0].DisplayName}
+	}
+	for i := range res.AccessControlList.Grants {
+		g := &res.AccessControlList.Grants[i]
+		g.Grantee.Type = g.Grantee.XMLXSI
+	}
+	return nil
+}
+
+// checkEmptyPermissions fails with ErrEmptyACLPermission on the first
+// grant without permission, or drops such grants when skip is set.
+func checkEmptyPermissions(grants []GrantDecode, skip bool) ([]GrantDecode, error) {
+	res := grants[:0]
+	for i, g := range grants {
+		if g.Permission != "" {
+			res = append(res, g)
+			continue
+		}
+		if !skip {
+			return nil, fmt.Errorf("%w: grant %d", ErrEmptyACLPermission, i)
+		}
+	}
+	return res, nil
+}
+
+// getACLString fetches the raw ACL XML of a bucket or an object.
+func (c *Client) getACLString(ctx context.Context, bucketName, objectName string, opts ACLOptions) (string, error) {
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	buf, _, err := c.getACL(ctx, bucketName, objectName, "", nil, opts)
+	if err != nil {
+		return "", err
+	}
+	defer putACLBuffer(buf)
+	return buf.String(), nil
+}
+
+// GetObjectACLstring returns the ACL of an object as the raw XML
+// document sent by the server.
+func (c *Client) GetObjectACLstring(ctx context.Context, bucketName, objectName string) (string, error) {
+	return c.GetObjectACLstringWithOptions(ctx, bucketName, objectName, ACLOptions{})
+}
+
+// GetObjectACLstringWithOptions is GetObjectACLstring with options.
+func (c *Client) GetObjectACLstringWithOptions(ctx context.Context, bucketName, objectName string, opts ACLOptions) (string, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return "", err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return "", err
+	}
+	return c.getACLString(ctx, bucketName, objectName, opts)
+}
+
+// GetObjectACLOptions holds options for GetObjectACLWithOptions.
+type GetObjectACLOptions struct {
+	ACLOptions
+
+	// VersionID selects the object version whose ACL is read. When set,
+	// a failing StatObject does not fail the call: the stat fields are
+	// left zero and the stat error is returned in ObjectInfo.Err.
+	VersionID string
+
+	// BucketOwnerID is the canonical ID of the bucket owner. It is
+	// needed to detect the bucket-owner-* canned ACLs; when empty
+	// those ACLs are reported through X-Amz-Grant-* headers.
+	BucketOwnerID string
+
+	// SkipStat avoids the StatObject request. The returned ObjectInfo
+	// then only carries the key, owner, grants and ACL metadata;
+	// Size, ETag and the other stat fields are left zero.
+	SkipStat bool
+
+	// ResolveNames fills the missing display names of the grantees
+	// with ResolveGranteeName, on a best-effort basis.
+	ResolveNames bool
+
+	// ACLTrace, when set, is called with the decoded policy, e.g. for
+	// audit logging without fetching the ACL a second time.
+	ACLTrace func(bucketName, objectName string, policy *AccessControlPolicyDecode)
+
+	// RequestPayer acknowledges that the requester is charged for the
+	// request, which requester-pays buckets require.
+	RequestPayer bool
+
+	// FailOnEmptyGrants makes an ACL without any grant fail with
+	// ErrEmptyACLGrants instead of returning an ObjectInfo without
+	// grants.
+	FailOnEmptyGrants bool
+
+	// UnknownPermission selects how grants with a permission unknown to
+	// S3, e.g. emitted by a gateway, are handled: UnknownPermissionSkip,
+	// the default, UnknownPermissionKeep or UnknownPermissionError.
+	UnknownPermission string
+
+	// AcceptJSON asks for a JSON ACL with an Accept: application/json
+	// header, which a few gateways honor. A JSON response, in the format
+	// of ACLToJSON, is decoded as such; an XML response is decoded as
+	// usual.
+	AcceptJSON bool
+
+	// ExpandFullControl also lists a FULL_CONTROL grantee in the
+	// X-Amz-Grant-Read, -Write, -Read-Acp and -Write-Acp metadata, see
+	// ExpandPermissions. Only X-Amz-Grant-Full-Control lists it by
+	// default.
+	ExpandFullControl bool
+}
+
+// Values of GetObjectACLOptions.UnknownPermission.
+const (
+	// UnknownPermissionSkip leaves the grants with an unknown permission
+	// in ObjectInfo.Grant, they are only left out of the X-Amz-Grant-*
+	// metadata.
+	UnknownPermissionSkip = "skip"
+	// UnknownPermissionKeep moves the grants with an unknown permission
+	// from ObjectInfo.Grant to ObjectInfo.UnknownGrants, the canned ACL
+	// is then detected on the known grants only.
+	UnknownPermissionKeep = "keep"
+	// UnknownPermissionError fails with ErrUnknownACLPermission.
+	UnknownPermissionError = "error"
+)
+
+// splitUnknownGrants separates the grants with a permission unknown to
+// S3 from the others, or fails according to mode.
+func splitUnknownGrants(grants []GrantDecode, mode string) (known, unknown []GrantDecode, err error) {
+	for _, g := range grants {
+		if validACLPermissions[g.Permission] {
+			known = append(known, g)
+			continue
+		}
+		if mode == UnknownPermissionError {
+			return nil, nil, fmt.Errorf("%w: %q", ErrUnknownACLPermission, g.Permission)
+		}
+		unknown = append(unknown, g)
+	}
+	return known, unknown, nil
+}
+
+// GetObjectACL get object ACLs
+func (c *Client) GetObjectACL(ctx context.Context, bucketName, objectName string) (*ObjectInfo, error) {
+	return c.GetObjectACLWithOptions(ctx, bucketName, objectName, GetObjectACLOptions{})
+}
+
+// GetObjectACLWithOptions get object ACLs with options.
+func (c *Client) GetObjectACLWithOptions(ctx context.Context, bucketName, objectName string, opts GetObjectACLOptions) (*ObjectInfo, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
 		return nil, err
 	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, err
+	}
+	switch opts.UnknownPermission {
+	case "", UnknownPermissionSkip, UnknownPermissionKeep, UnknownPermissionError:
+	default:
+		return nil, errInvalidArgument(fmt.Sprintf("Invalid UnknownPermission mode %q.", opts.UnknownPermission))
+	}
 
-	objInfo, err := c.StatObject(ctx, bucketName, objectName, StatObjectOptions{})
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	customHeader := requestPayerHeader(nil, opts.RequestPayer)
+	if opts.AcceptJSON {
+		if customHeader == nil {
+			customHeader = make(http.Header)
+		}
+		customHeader.Set("Accept", "application/json")
+	}
+	res, respHeader, err := c.getACLPolicyHeader(ctx, bucketName, objectName, opts.VersionID, customHeader, opts.ACLOptions)
 	if err != nil {
 		return nil, err
 	}
+	if opts.ACLTrace != nil {
+		opts.ACLTrace(bucketName, objectName, res)
+	}
+	if opts.FailOnEmptyGrants && len(res.AccessControlList.Grants) == 0 {
+		return nil, fmt.Errorf("%w: object %s/%s", ErrEmptyACLGrants, bucketName, objectName)
+	}
+	grants, unknownGrants, err := splitUnknownGrants(res.AccessControlList.Grants, opts.UnknownPermission)
+	if err != nil {
+		return nil, fmt.Errorf("object %s/%s: %w", bucketName, objectName, err)
+	}
+	if opts.UnknownPermission == UnknownPermissionKeep {
+		res.AccessControlList.Grants = grants
+	}
 
+	var objInfo ObjectInfo
+	if opts.SkipStat {
+		objInfo = ObjectInfo{
+			Key:       objectName,
+			VersionID: opts.VersionID,
+			Metadata:  make(http.Header),
+		}
+	} else {
+		statOpts := StatObjectOptions{VersionID: opts.VersionID}
+		if opts.RequestPayer {
+			statOpts.Set(amzRequestPayer, "requester")
+		}
+		// The HEAD carries the extra and expected bucket owner headers
+		// too, they were validated by the ACL request.
+		statHeader, _ := opts.setHeaders(nil)
+		for k, v := range statHeader {
+			statOpts.Set(k, v[0])
+		}
+		objInfo, err = c.StatObject(ctx, bucketName, objectName, statOpts)
+		if err != nil {
+			if opts.VersionID == "" {
+				return nil, err
+			}
+			// The ACL of a version may be readable while the version
+			// cannot be stat'ed, e.g. behind a delete marker. Keep the
+			// ACL and report the stat failure through Err.
+			objInfo = ObjectInfo{
+				Key:            objectName,
+				VersionID:      opts.VersionID,
+				IsDeleteMarker: objInfo.IsDeleteMarker,
+				Metadata:       make(http.Header),
+				Err:            err,
+			}
+		}
+	}
+
+	// Report the version whose ACL was read.
+	if versionID := respHeader.Get(amzVersionID); versionID != "" {
+		objInfo.VersionID = versionID
+	}
 	objInfo.Owner.DisplayName = res.Owner.DisplayName
 	objInfo.Owner.ID = res.Owner.ID
 
-	objInfo.Grant = append(objInfo.Grant, res.AccessControlList.Grant...)
+	if opts.ResolveNames {
+		c.resolveGranteeNames(ctx, res.AccessControlList.Grants)
+	}
+	objInfo.Grant = append(objInfo.Grant, res.AccessControlList.Grants...)
+	if opts.UnknownPermission == UnknownPermissionKeep {
+		objInfo.UnknownGrants = unknownGrants
+	}
 
-	cannedACL := getCannedACL(res)
+	cannedACL := getCannedACL(res, opts.BucketOwnerID)
 	if cannedACL != "" {
 		objInfo.Metadata.Add("X-Amz-Acl", cannedACL)
 		return &objInfo, nil
 	}
 
-	grantACL := getAmzGrantACL(res)
+	grantACL := getAmzGrantACL(res, opts.ExpandFullControl)
 	for k, v := range grantACL {
 		objInfo.Metadata[k] = v
 	}
@@ -100,53 +673,244 @@ func (c *Client) GetObjectACL(ctx context.Context, bucketName, objectName string
 	return &objInfo, nil
 }
 
-func getCannedACL(aCPolicy *accessControlPolicy) string {
-	grants := aCPolicy.AccessControlList.Grant
+// GetObjectCannedACL returns the name of the canned ACL matching the
+// grants of an object, or an empty string and ErrCustomACL when none
+// matches.
+func (c *Client) GetObjectCannedACL(ctx context.Context, bucketName, objectName string) (string, error) {
+	return c.GetObjectCannedACLWithOptions(ctx, bucketName, objectName, GetObjectACLOptions{})
+}
+
+// GetObjectCannedACLWithOptions is GetObjectCannedACL with options. Set
+// opts.BucketOwnerID to detect the bucket-owner-* canned ACLs. The stat
+// related options are ignored.
+func (c *Client) GetObjectCannedACLWithOptions(ctx context.Context, bucketName, objectName string, opts GetObjectACLOptions) (string, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return "", err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	acld, err := c.getACLPolicy(ctx, bucketName, objectName, opts.VersionID, requestPayerHeader(nil, opts.RequestPayer), opts.ACLOptions)
+	if err != nil {
+		return "", err
+	}
+	if canned := getCannedACL(acld, opts.BucketOwnerID); canned != "" {
+		return canned, nil
+	}
+	return "", ErrCustomACL
+}
+
+// getCannedACL returns the canned ACL matching the policy grants, or an
+// empty string when there is none. bucketOwnerID may be empty when the
+// bucket owner is unknown.
+func getCannedACL(aCPolicy *AccessControlPolicyDecode, bucketOwnerID string) string {
+	grants := aCPolicy.AccessControlList.Grants
 
 	switch {
 	case len(grants) == 1:
-		if grants[0].Grantee.URI == "" && grants[0].Permission == "FULL_CONTROL" {
+		if grants[0].Grantee.URI == "" && grants[0].Permission == PermissionFullControl {
 			return "private"
 		}
 	case len(grants) == 2:
+		if bucketOwnerID != "" && bucketOwnerID != aCPolicy.Owner.ID && hasFullControl(grants, aCPolicy.Owner.ID) {
+			if hasFullControl(grants, bucketOwnerID) {
+				return "bucket-owner-full-control"
+			}
+			if hasPermission(grants, bucketOwnerID, PermissionRead) {
+				return "bucket-owner-read"
+			}
+		}
 		for _, g := range grants {
-			if g.Grantee.URI == "http://acs.amazonaws.com/groups/global/AuthenticatedUsers" && g.Permission == "READ" {
+			if g.Grantee.URI == GroupAuthenticatedUsers && g.Permission == PermissionRead {
 				return "authenticated-read"
 			}
-			if g.Grantee.URI == "http://acs.amazonaws.com/groups/global/AllUsers" && g.Permission == "READ" {
+			if g.Grantee.URI == GroupAllUsers && g.Permission == PermissionRead {
 				return "public-read"
 			}
-			if g.Permission == "READ" && g.Grantee.ID == aCPolicy.Owner.ID {
-				return "bucket-owner-read"
-			}
 		}
 	case len(grants) == 3:
+		var logDeliveryWrite, logDeliveryReadACP bool
 		for _, g := range grants {
-			if g.Grantee.URI == "http://acs.amazonaws.com/groups/global/AllUsers" && g.Permission == "WRITE" {
+			if g.Grantee.URI == GroupAllUsers && g.Permission == PermissionWrite {
 				return "public-read-write"
 			}
+			if g.Grantee.URI == GroupLogDelivery {
+				switch g.Permission {
+				case PermissionWrite:
+					logDeliveryWrite = true
+				case PermissionReadACP:
+					logDeliveryReadACP = true
+				}
+			}
+		}
+		if logDeliveryWrite && logDeliveryReadACP && hasFullControl(grants, aCPolicy.Owner.ID) {
+			return "log-delivery-write"
 		}
 	}
 	return ""
 }
 
-func getAmzGrantACL(aCPolicy *accessControlPolicy) map[string][]string {
-	grants := aCPolicy.AccessControlList.Grant
+// hasFullControl reports whether the canonical user id holds FULL_CONTROL.
+func hasFullControl(grants []GrantDecode, id string) bool {
+	return hasPermission(grants, id, PermissionFullControl)
+}
+
+// hasPermission reports whether the canonical user id holds a grant of
+// exactly perm.
+func hasPermission(grants []GrantDecode, id, perm string) bool {
+	for _, g := range grants {
+		if g.Grantee.URI == "" && g.Grantee.ID == id && g.Permission == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionGrantHeaders maps the permissions to their x-amz-grant-*
+// header.
+var permissionGrantHeaders = map[string]string{
+	PermissionRead:        "X-Amz-Grant-Read",
+	PermissionWrite:       "X-Amz-Grant-Write",
+	PermissionReadACP:     "X-Amz-Grant-Read-Acp",
+	PermissionWriteACP:    "X-Amz-Grant-Write-Acp",
+	PermissionFullControl: "X-Amz-Grant-Full-Control",
+}
+
+// getAmzGrantACL returns the grants of the policy in x-amz-grant-* header
+// form. With expandFullControl, a FULL_CONTROL grantee is also listed in
+// the headers of the permissions it implies, see ExpandPermissions.
+func getAmzGrantACL(aCPolicy *AccessControlPolicyDecode, expandFullControl bool) map[string][]string {
+	grants := aCPolicy.AccessControlList.Grants
 	res := map[string][]string{}
 
+	seen := make(map[string]bool)
 	for _, g := range grants {
-		switch {
-		case g.Permission == "READ":
-			res["X-Amz-Grant-Read"] = append(res["X-Amz-Grant-Read"], "id="+g.Grantee.ID)
-		case g.Permission == "WRITE":
-			res["X-Amz-Grant-Write"] = append(res["X-Amz-Grant-Write"], "id="+g.Grantee.ID)
-		case g.Permission == "READ_ACP":
-			res["X-Amz-Grant-Read-Acp"] = append(res["X-Amz-Grant-Read-Acp"], "id="+g.Grantee.ID)
-		case g.Permission == "WRITE_ACP":
-			res["X-Amz-Grant-Write-Acp"] = append(res["X-Amz-Grant-Write-Acp"], "id="+g.Grantee.ID)
-		case g.Permission == "FULL_CONTROL":
-			res["X-Amz-Grant-Full-Control"] = append(res["X-Amz-Grant-Full-Control"], "id="+g.Grantee.ID)
+		grantee := granteeHeaderValue(g.Grantee)
+		perms := []string{g.Permission}
+		if expandFullControl {
+			perms = ExpandPermissions(g.Permission)
+		}
+		for _, perm := range perms {
+			header, ok := permissionGrantHeaders[perm]
+			if !ok {
+				continue
+			}
+			if expandFullControl {
+				// A grantee may hold both FULL_CONTROL and a narrower
+				// permission, list it once.
+				if seen[header+" "+grantee] {
+					continue
+				}
+				seen[header+" "+grantee] = true
+			}
+			res[header] = append(res[header], grantee)
 		}
 	}
 	return res
 }
+
+// granteeHeaderValue formats a grantee following the x-amz-grant-*
+// header grammar, i.e. id="...", uri="..." or emailAddress="...".
+func granteeHeaderValue(g GranteeDecode) string {
+	switch {
+	case g.Type == GranteeTypeGroup, g.Type == "" && g.URI != "":
+		return `uri="` + g.URI + `"`
+	case g.Type == GranteeTypeEmail, g.Type == "" && g.Email != "":
+		return `emailAddress="` + g.Email + `"`
+	default:
+		return `id="` + g.ID + `"`
+	}
+}
+
+// ParseGrantHeader parses the value of an x-amz-grant-* header, a
+// comma-separated list of id="...", uri="..." and emailAddress="..."
+// grantees, into grants of the given permission. It is the inverse of
+// the header form returned by GetBucketACLInfo.
+func ParseGrantHeader(permission, headerValue string) ([]GrantEncode, error) {
+	if !validACLPermissions[permission] {
+		return nil, errInvalidArgument(fmt.Sprintf("Invalid permission %q.", permission))
+	}
+	var grants []GrantEncode
+	rest := strings.TrimSpace(headerValue)
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return nil, errInvalidArgument(fmt.Sprintf("Malformed grant %q, expected key=\"value\".", rest))
+		}
+		key := strings.TrimSpace(rest[:eq])
+		rest = strings.TrimLeft(rest[eq+1:], " ")
+		if !strings.HasPrefix(rest, `"`) {
+			return nil, errInvalidArgument(fmt.Sprintf("Unquoted value for grantee key %q.", key))
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return nil, errInvalidArgument(fmt.Sprintf("Unterminated value for grantee key %q.", key))
+		}
+		value := rest[1 : end+1]
+		rest = strings.TrimSpace(rest[end+2:])
+		if rest != "" {
+			if rest[0] != ',' {
+				return nil, errInvalidArgument(fmt.Sprintf("Expected a comma after grantee %s=%q.", key, value))
+			}
+			rest = strings.TrimSpace(rest[1:])
+			if rest == "" {
+				return nil, errInvalidArgument("Trailing comma in grant header.")
+			}
+		}
+		if value == "" {
+			return nil, errInvalidArgument(fmt.Sprintf("Empty value for grantee key %q.", key))
+		}
+
+		var grantee GranteeEncode
+		switch strings.ToLower(key) {
+		case "id":
+			grantee = newGranteeEncode(GranteeTypeCanonicalUser)
+			grantee.ID = value
+		case "uri":
+			grantee = newGranteeEncode(GranteeTypeGroup)
+			grantee.URI = value
+		case "emailaddress":
+			grantee = newGranteeEncode(GranteeTypeEmail)
+			grantee.Email = value
+		default:
+			return nil, errInvalidArgument(fmt.Sprintf("Unknown grantee key %q.", key))
+		}
+		grants = append(grants, GrantEncode{Grantee: grantee, Permission: permission})
+	}
+	if len(grants) == 0 {
+		return nil, errInvalidArgument("Grant header cannot be empty.")
+	}
+	return grants, nil
+}
+
+// GrantsByPermission returns the ACL grants of the object grouped by
+// permission.
+func (o ObjectInfo) GrantsByPermission() map[string][]GrantDecode {
+	res := make(map[string][]GrantDecode)
+	for _, g := range o.Grant {
+		res[g.Permission] = append(res[g.Permission], g)
+	}
+	return res
+}
+
+// PermissionsFor returns the distinct permissions granted to the
+// grantee identified by granteeID, which is matched against the
+// canonical ID, group URI and email address of each grantee.
+func (o ObjectInfo) PermissionsFor(granteeID string) []string {
+	var perms []string
+	seen := make(map[string]bool)
+	for _, g := range o.Grant {
+		if granteeID != g.Grantee.ID && granteeID != g.Grantee.URI && granteeID != g.Grantee.Email {
+			continue
+		}
+		if !seen[g.Permission] {
+			seen[g.Permission] = true
+			perms = append(perms, g.Permission)
+		}
+	}
+	return perms
+}
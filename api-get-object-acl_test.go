@@ -0,0 +1,1258 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetAmzGrantACL(t *testing.T) {
+	canonical := GranteeDecode{Type: "CanonicalUser", ID: "abc123"}
+	group := GranteeDecode{Type: "Group", URI: "http://acs.amazonaws.com/groups/global/AllUsers"}
+	email := GranteeDecode{Type: "AmazonCustomerByEmail", Email: "user@example.com"}
+
+	headers := map[string]string{
+		"READ":         "X-Amz-Grant-Read",
+		"WRITE":        "X-Amz-Grant-Write",
+		"READ_ACP":     "X-Amz-Grant-Read-Acp",
+		"WRITE_ACP":    "X-Amz-Grant-Write-Acp",
+		"FULL_CONTROL": "X-Amz-Grant-Full-Control",
+	}
+
+	testCases := []struct {
+		grantee GranteeDecode
+		value   string
+	}{
+		{canonical, `id="abc123"`},
+		{group, `uri="http://acs.amazonaws.com/groups/global/AllUsers"`},
+		{email, `emailAddress="user@example.com"`},
+		// Grantees without xsi:type fall back to the populated field.
+		{GranteeDecode{URI: "http://acs.amazonaws.com/groups/s3/LogDelivery"}, `uri="http://acs.amazonaws.com/groups/s3/LogDelivery"`},
+		{GranteeDecode{Email: "user@example.com"}, `emailAddress="user@example.com"`},
+		{GranteeDecode{ID: "abc123"}, `id="abc123"`},
+	}
+
+	for i, testCase := range testCases {
+		for perm, header := range headers {
+			policy := &AccessControlPolicyDecode{}
+			policy.AccessControlList.Grants = []GrantDecode{{Grantee: testCase.grantee, Permission: perm}}
+			got := getAmzGrantACL(policy, false)
+			want := map[string][]string{header: {testCase.value}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Test %d (%s): expected %v, got %v", i+1, perm, want, got)
+			}
+		}
+	}
+}
+
+func TestGetAmzGrantACLMultipleGrantees(t *testing.T) {
+	policy := &AccessControlPolicyDecode{}
+	policy.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "abc123"}, Permission: "READ"},
+		{Grantee: GranteeDecode{Type: "Group", URI: "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"}, Permission: "READ"},
+	}
+	want := map[string][]string{
+		"X-Amz-Grant-Read": {`id="abc123"`, `uri="http://acs.amazonaws.com/groups/global/AuthenticatedUsers"`},
+	}
+	if got := getAmzGrantACL(policy, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGetAmzGrantACLExpandFullControl(t *testing.T) {
+	policy := &AccessControlPolicyDecode{Owner: Owner{ID: "owner"}}
+	policy.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "abc123"}, Permission: "FULL_CONTROL"},
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "abc123"}, Permission: "READ"},
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "def456"}, Permission: "WRITE"},
+	}
+
+	literal := map[string][]string{
+		"X-Amz-Grant-Full-Control": {`id="abc123"`},
+		"X-Amz-Grant-Read":         {`id="abc123"`},
+		"X-Amz-Grant-Write":        {`id="def456"`},
+	}
+	if got := getAmzGrantACL(policy, false); !reflect.DeepEqual(got, literal) {
+		t.Errorf("expected %v, got %v", literal, got)
+	}
+	expanded := map[string][]string{
+		"X-Amz-Grant-Full-Control": {`id="abc123"`},
+		"X-Amz-Grant-Read":         {`id="abc123"`},
+		"X-Amz-Grant-Write":        {`id="abc123"`, `id="def456"`},
+		"X-Amz-Grant-Read-Acp":     {`id="abc123"`},
+		"X-Amz-Grant-Write-Acp":    {`id="abc123"`},
+	}
+	if got := getAmzGrantACL(policy, true); !reflect.DeepEqual(got, expanded) {
+		t.Errorf("expected %v, got %v", expanded, got)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testACLXML(testUserGrantXML("abc123", "FULL_CONTROL"), testUserGrantXML("def456", "WRITE"))))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	for _, expand := range []bool{false, true} {
+		info, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{SkipStat: true, ExpandFullControl: expand})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{`id="def456"`}
+		if expand {
+			want = []string{`id="abc123"`, `id="def456"`}
+		}
+		if got := info.Metadata["X-Amz-Grant-Write"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("ExpandFullControl %v: expected %v, got %v", expand, want, got)
+		}
+	}
+}
+
+func TestGetCannedACLBucketOwnerFullControl(t *testing.T) {
+	policy := &AccessControlPolicyDecode{Owner: Owner{ID: "object-owner"}}
+	policy.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "FULL_CONTROL"},
+		{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "bucket-owner"}, Permission: "FULL_CONTROL"},
+	}
+
+	testCases := []struct {
+		bucketOwnerID string
+		canned        string
+	}{
+		{"bucket-owner", "bucket-owner-full-control"},
+		// Unknown bucket owner falls back to grant headers.
+		{"", ""},
+		// Bucket owner not part of the grants.
+		{"other-owner", ""},
+	}
+
+	for i, testCase := range testCases {
+		if got := getCannedACL(policy, testCase.bucketOwnerID); got != testCase.canned {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.canned, got)
+		}
+	}
+}
+
+func TestGetCannedACLBucketOwnerRead(t *testing.T) {
+	testCases := []struct {
+		grants        []GrantDecode
+		bucketOwnerID string
+		canned        string
+	}{
+		{
+			[]GrantDecode{
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "FULL_CONTROL"},
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "bucket-owner"}, Permission: "READ"},
+			},
+			"bucket-owner", "bucket-owner-read",
+		},
+		// Unknown bucket owner falls back to grant headers.
+		{
+			[]GrantDecode{
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "FULL_CONTROL"},
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "bucket-owner"}, Permission: "READ"},
+			},
+			"", "",
+		},
+		// READ granted to someone else than the bucket owner.
+		{
+			[]GrantDecode{
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "FULL_CONTROL"},
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "someone"}, Permission: "READ"},
+			},
+			"bucket-owner", "",
+		},
+		// The object owner reading its own object is not bucket-owner-read.
+		{
+			[]GrantDecode{
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "READ"},
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "bucket-owner"}, Permission: "READ"},
+			},
+			"bucket-owner", "",
+		},
+		// The object owner does not keep FULL_CONTROL.
+		{
+			[]GrantDecode{
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "object-owner"}, Permission: "WRITE"},
+				{Grantee: GranteeDecode{Type: "CanonicalUser", ID: "bucket-owner"}, Permission: "READ"},
+			},
+			"bucket-owner", "",
+		},
+	}
+
+	for i, testCase := range testCases {
+		policy := &AccessControlPolicyDecode{Owner: Owner{ID: "object-owner"}}
+		policy.AccessControlList.Grants = testCase.grants
+		if got := getCannedACL(policy, testCase.bucketOwnerID); got != testCase.canned {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.canned, got)
+		}
+	}
+}
+
+const testLogDeliveryACLXML = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner>
+    <ID>852b113e7a2f25102679df27bb0ae12b3f85be6BucketOwnerCanonicalUserID</ID>
+    <DisplayName>owner</DisplayName>
+  </Owner>
+  <AccessControlList>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser">
+        <ID>852b113e7a2f25102679df27bb0ae12b3f85be6BucketOwnerCanonicalUserID</ID>
+        <DisplayName>owner</DisplayName>
+      </Grantee>
+      <Permission>FULL_CONTROL</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group">
+        <URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI>
+      </Grantee>
+      <Permission>WRITE</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group">
+        <URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI>
+      </Grantee>
+      <Permission>READ_ACP</Permission>
+    </Grant>
+  </AccessControlList>
+</AccessControlPolicy>`
+
+func TestGetCannedACLLogDeliveryWrite(t *testing.T) {
+	policy := &AccessControlPolicyDecode{}
+	if err := xmlDecoder(strings.NewReader(testLogDeliveryACLXML), policy); err != nil {
+		t.Fatal(err)
+	}
+	if got := getCannedACL(policy, ""); got != "log-delivery-write" {
+		t.Fatalf("expected %q, got %q", "log-delivery-write", got)
+	}
+
+	// Without READ_ACP the grants no longer match the canned ACL.
+	policy.AccessControlList.Grants[2].Permission = "READ"
+	if got := getCannedACL(policy, ""); got != "" {
+		t.Fatalf("expected no canned ACL, got %q", got)
+	}
+}
+
+func TestGetObjectACLSkipStat(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", "FULL_CONTROL").Build()
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{SkipStat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodHead); n != 0 {
+		t.Fatalf("expected no HEAD request, got %d", n)
+	}
+	if objInfo.Key != "object" || objInfo.Owner.ID != "owner" || len(objInfo.Grant) != 1 {
+		t.Fatalf("unexpected object info %+v", objInfo)
+	}
+	if objInfo.Size != 0 || objInfo.ETag != "" {
+		t.Fatalf("expected zero stat fields, got size %d and etag %q", objInfo.Size, objInfo.ETag)
+	}
+	if got := objInfo.Metadata.Get("X-Amz-Acl"); got != "private" {
+		t.Fatalf("expected canned ACL %q, got %q", "private", got)
+	}
+
+	if _, err = clnt.GetObjectACL(ctx, "bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodHead); n != 1 {
+		t.Fatalf("expected 1 HEAD request, got %d", n)
+	}
+}
+
+// testACLXML returns an AWS style AccessControlPolicy document owned by
+// the canonical user "owner" with the given Grant elements.
+func testACLXML(grants ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>owner</ID><DisplayName>owner</DisplayName></Owner><AccessControlList>` +
+		strings.Join(grants, "") + `</AccessControlList></AccessControlPolicy>`
+}
+
+// testUserGrantXML returns a Grant element for a canonical user.
+func testUserGrantXML(id, perm string) string {
+	return `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>` + id +
+		`</ID></Grantee><Permission>` + perm + `</Permission></Grant>`
+}
+
+// testGroupGrantXML returns a Grant element for a group.
+func testGroupGrantXML(uri, perm string) string {
+	return `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>` + uri +
+		`</URI></Grantee><Permission>` + perm + `</Permission></Grant>`
+}
+
+func TestGetCannedACLFixtures(t *testing.T) {
+	ownerFull := testUserGrantXML("owner", "FULL_CONTROL")
+	testCases := []struct {
+		xml    string
+		canned string
+	}{
+		{testACLXML(ownerFull), "private"},
+		{testBucketACLXML, "public-read"},
+		{testACLXML(ownerFull, testGroupGrantXML("http://acs.amazonaws.com/groups/global/AllUsers", "READ"), testGroupGrantXML("http://acs.amazonaws.com/groups/global/AllUsers", "WRITE")), "public-read-write"},
+		{testACLXML(ownerFull, testGroupGrantXML("http://acs.amazonaws.com/groups/global/AuthenticatedUsers", "READ")), "authenticated-read"},
+		{testLogDeliveryACLXML, "log-delivery-write"},
+		{testACLXML(ownerFull, testUserGrantXML("abc123", "WRITE")), ""},
+	}
+
+	for i, testCase := range testCases {
+		policy := &AccessControlPolicyDecode{}
+		if err := xmlDecoder(strings.NewReader(testCase.xml), policy); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if got := getCannedACL(policy, ""); got != testCase.canned {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.canned, got)
+		}
+	}
+}
+
+func TestGranteeDecodeXSIAttributes(t *testing.T) {
+	policy := &AccessControlPolicyDecode{}
+	if err := xmlDecoder(strings.NewReader(testBucketACLXML), policy); err != nil {
+		t.Fatal(err)
+	}
+	grantee := policy.AccessControlList.Grants[0].Grantee
+	if grantee.XMLXSI != "CanonicalUser" {
+		t.Fatalf("expected xsi:type %q, got %q", "CanonicalUser", grantee.XMLXSI)
+	}
+	if grantee.XMLNS != "http://www.w3.org/2001/XMLSchema-instance" {
+		t.Fatalf("expected xmlns:xsi %q, got %q", "http://www.w3.org/2001/XMLSchema-instance", grantee.XMLNS)
+	}
+
+	// Re-encoding keeps the grantee type attribute.
+	buf, err := xml.Marshal(policyDecodeToEncode(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser">`
+	if !strings.Contains(string(buf), want) {
+		t.Fatalf("re-encoded ACL %s does not contain %s", buf, want)
+	}
+}
+
+func TestGetObjectACLResolveNames(t *testing.T) {
+	stub := newACLTestServer()
+	stub.owner = Owner{ID: "owner-id", DisplayName: "Owner"}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acle := NewACLBuilder(Owner{ID: "owner-id"}).
+		GrantCanonicalUser("owner-id", "FULL_CONTROL").
+		GrantCanonicalUser("owner-id", "READ").
+		GrantCanonicalUser("other-id", "READ").
+		GrantCanonicalUser("third-id", "READ").
+		GrantGroup("http://acs.amazonaws.com/groups/global/AllUsers", "READ").
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{SkipStat: true, ResolveNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, g := range objInfo.Grant {
+		names = append(names, g.Grantee.DisplayName)
+	}
+	if strings.Join(names, ",") != "Owner,Owner,,," {
+		t.Fatalf("unexpected display names %q", names)
+	}
+
+	// A single lookup whatever the number of distinct canonical IDs.
+	lookups := 0
+	for _, r := range stub.reqs {
+		if r.URL.Path == "/" {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("expected 1 lookup, got %d", lookups)
+	}
+
+	name, err := clnt.ResolveGranteeName(ctx, "owner-id")
+	if err != nil || name != "Owner" {
+		t.Fatalf("expected %q, got %q (%v)", "Owner", name, err)
+	}
+}
+
+func TestGetObjectACLResolveNamesBestEffort(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", NewACLBuilder(Owner{ID: "owner-id"}).GrantCanonicalUser("owner-id", "FULL_CONTROL").Build()); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{SkipStat: true, ResolveNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Grant[0].Grantee.DisplayName != "" {
+		t.Fatalf("expected an empty display name, got %q", objInfo.Grant[0].Grantee.DisplayName)
+	}
+}
+
+func TestObjectInfoGrantHelpers(t *testing.T) {
+	user := GranteeDecode{Type: "CanonicalUser", ID: "abc123"}
+	group := GranteeDecode{Type: "Group", URI: "http://acs.amazonaws.com/groups/global/AllUsers"}
+	objInfo := ObjectInfo{
+		Grant: []GrantDecode{
+			{Grantee: user, Permission: "READ"},
+			{Grantee: user, Permission: "WRITE"},
+			{Grantee: user, Permission: "READ"},
+			{Grantee: group, Permission: "READ"},
+		},
+	}
+
+	byPerm := objInfo.GrantsByPermission()
+	if len(byPerm) != 2 || len(byPerm["READ"]) != 3 || len(byPerm["WRITE"]) != 1 {
+		t.Fatalf("unexpected grants by permission %+v", byPerm)
+	}
+
+	testCases := []struct {
+		granteeID string
+		perms     []string
+	}{
+		{"abc123", []string{"READ", "WRITE"}},
+		{"http://acs.amazonaws.com/groups/global/AllUsers", []string{"READ"}},
+		{"unknown", nil},
+	}
+	for i, testCase := range testCases {
+		if got := objInfo.PermissionsFor(testCase.granteeID); !reflect.DeepEqual(got, testCase.perms) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.perms, got)
+		}
+	}
+}
+
+func TestGetACLResponseTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testBucketACLXML))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	opts := ACLOptions{MaxResponseSize: int64(len(testBucketACLXML))}
+	if _, err := clnt.GetBucketACLWithOptions(ctx, "bucket", opts); err != nil {
+		t.Fatalf("expected a body of exactly the limit to be read, got %v", err)
+	}
+	if acl, err := clnt.GetObjectACLstringWithOptions(ctx, "bucket", "object", opts); err != nil || acl != testBucketACLXML {
+		t.Fatalf("expected a body of exactly the limit to be read, got %q, %v", acl, err)
+	}
+
+	opts.MaxResponseSize = int64(len(testBucketACLXML)) - 1
+	_, errb := clnt.GetBucketACLWithOptions(ctx, "bucket", opts)
+	_, erro := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{ACLOptions: opts})
+	_, errc := clnt.GetObjectCannedACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{ACLOptions: opts})
+	_, errbs := clnt.GetBucketACLstringWithOptions(ctx, "bucket", opts)
+	_, erros := clnt.GetObjectACLstringWithOptions(ctx, "bucket", "object", opts)
+	for i, err := range []error{errb, erro, errc, errbs, erros} {
+		if err != ErrACLResponseTooLarge {
+			t.Errorf("Test %d: expected %v, got %v", i+1, ErrACLResponseTooLarge, err)
+		}
+	}
+
+	// The default limit applies without options.
+	large := bytes.Repeat([]byte(" "), MaxACLResponseSize+1)
+	if _, err := readACLBody(bytes.NewReader(large), ACLOptions{}.maxResponseSize()); err != ErrACLResponseTooLarge {
+		t.Errorf("expected %v, got %v", ErrACLResponseTooLarge, err)
+	}
+}
+
+// testMinIOUntypedACLXML is an ACL as sent by MinIO builds that omit
+// the xsi:type attribute of the grantees.
+const testMinIOUntypedACLXML = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>02d6176db174dc93cb1b899f7c6078f08654445fe8cf1b6ce98d8855f66bdbf4</ID><DisplayName>minio</DisplayName></Owner><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><ID>02d6176db174dc93cb1b899f7c6078f08654445fe8cf1b6ce98d8855f66bdbf4</ID><DisplayName>minio</DisplayName></Grantee><Permission>FULL_CONTROL</Permission></Grant><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><EmailAddress>user@example.com</EmailAddress></Grantee><Permission>WRITE_ACP</Permission></Grant></AccessControlList></AccessControlPolicy>`
+
+func TestGetObjectACLInfersGranteeType(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testMinIOUntypedACLXML
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	objInfo, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{SkipStat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTypes := []string{GranteeTypeCanonicalUser, GranteeTypeGroup, GranteeTypeEmail}
+	if len(objInfo.Grant) != len(wantTypes) {
+		t.Fatalf("expected %d grants, got %d", len(wantTypes), len(objInfo.Grant))
+	}
+	for i, g := range objInfo.Grant {
+		if g.Grantee.Type != wantTypes[i] {
+			t.Errorf("grant %d: expected type %q, got %q", i, wantTypes[i], g.Grantee.Type)
+		}
+		if g.Grantee.XMLXSI != "" {
+			t.Errorf("grant %d: expected the raw xsi:type to stay empty, got %q", i, g.Grantee.XMLXSI)
+		}
+	}
+	if got := objInfo.Metadata.Get("X-Amz-Grant-Write-Acp"); got != `emailAddress="user@example.com"` {
+		t.Errorf("unexpected X-Amz-Grant-Write-Acp %q", got)
+	}
+
+	// The inferred types are carried over when the ACL is sent back.
+	acld, err := clnt.getACLPolicy(context.Background(), "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, g := range policyDecodeToEncode(acld).AccessControlList.Grants {
+		if g.Grantee.XMLXSI != wantTypes[i] {
+			t.Errorf("grant %d: expected xsi:type %q, got %q", i, wantTypes[i], g.Grantee.XMLXSI)
+		}
+	}
+}
+
+func TestACLNameValidation(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+
+	objectCalls := map[string]func(bucketName, objectName string) error{
+		"GetObjectACL": func(b, o string) error {
+			_, err := clnt.GetObjectACL(ctx, b, o)
+			return err
+		},
+		"GetObjectACLstring": func(b, o string) error {
+			_, err := clnt.GetObjectACLstring(ctx, b, o)
+			return err
+		},
+		"PutObjectAcl": func(b, o string) error {
+			return clnt.PutObjectAcl(ctx, b, o, acle)
+		},
+		"PutObjectACLWithOptions": func(b, o string) error {
+			return clnt.PutObjectACLWithOptions(ctx, b, o, acle, PutObjectACLOptions{FillOwner: true})
+		},
+		"PutObjectACLstring": func(b, o string) error {
+			return clnt.PutObjectACLstring(ctx, b, o, "<AccessControlPolicy/>")
+		},
+		"PutObjectACLCanned": func(b, o string) error {
+			return clnt.PutObjectACLCanned(ctx, b, o, "private")
+		},
+		"CopyObjectACL source": func(b, o string) error {
+			_, err := clnt.CopyObjectACL(ctx, b, o, "bucket", "object")
+			return err
+		},
+		"CopyObjectACL destination": func(b, o string) error {
+			_, err := clnt.CopyObjectACL(ctx, "bucket", "object", b, o)
+			return err
+		},
+	}
+	bucketCalls := map[string]func(bucketName string) error{
+		"GetBucketACL": func(b string) error {
+			_, err := clnt.GetBucketACL(ctx, b)
+			return err
+		},
+		"GetBucketACLstring": func(b string) error {
+			_, err := clnt.GetBucketACLstring(ctx, b)
+			return err
+		},
+		"PutBucketAcl": func(b string) error {
+			return clnt.PutBucketAcl(ctx, b, acle)
+		},
+		"PutBucketACLstring": func(b string) error {
+			return clnt.PutBucketACLstring(ctx, b, "<AccessControlPolicy/>")
+		},
+		"PutBucketACLCanned": func(b string) error {
+			return clnt.PutBucketACLCanned(ctx, b, "private")
+		},
+	}
+
+	invalidBuckets := []string{"", "  ", "ab", "192.168.1.1"}
+	for name, call := range objectCalls {
+		for _, b := range invalidBuckets {
+			if err := call(b, "object"); err == nil {
+				t.Errorf("%s: expected an error for bucket %q", name, b)
+			}
+		}
+		for _, o := range []string{"", "   ", "\xff"} {
+			if err := call("bucket", o); err == nil {
+				t.Errorf("%s: expected an error for object %q", name, o)
+			}
+		}
+	}
+	for name, call := range bucketCalls {
+		for _, b := range invalidBuckets {
+			if err := call(b); err == nil {
+				t.Errorf("%s: expected an error for bucket %q", name, b)
+			}
+		}
+	}
+	if len(stub.reqs) != 0 {
+		t.Fatalf("expected no request to reach the server, got %d", len(stub.reqs))
+	}
+}
+
+func TestGetObjectACLFailOnEmptyGrants(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/empty?versionId="] = testACLXML()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "empty", GetObjectACLOptions{SkipStat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objInfo.Grant) != 0 {
+		t.Fatalf("expected no grants, got %d", len(objInfo.Grant))
+	}
+
+	opts := GetObjectACLOptions{SkipStat: true, FailOnEmptyGrants: true}
+	if _, err = clnt.GetObjectACLWithOptions(ctx, "bucket", "empty", opts); !errors.Is(err, ErrEmptyACLGrants) {
+		t.Fatalf("expected ErrEmptyACLGrants, got %v", err)
+	}
+	if _, err = clnt.GetObjectACLWithOptions(ctx, "bucket", "object", opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetObjectACLVersionStatFailure(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId=v1"] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// The current version is a delete marker.
+			w.Header().Set("X-Amz-Delete-Marker", "true")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{VersionID: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Err == nil || !objInfo.IsDeleteMarker {
+		t.Fatalf("expected the stat failure to be flagged, got %+v", objInfo)
+	}
+	if objInfo.Key != "object" || objInfo.VersionID != "v1" || objInfo.Size != 0 {
+		t.Fatalf("unexpected object info %+v", objInfo)
+	}
+	if objInfo.Owner.ID != "owner" || len(objInfo.Grant) != 1 || objInfo.Metadata.Get("X-Amz-Acl") != "private" {
+		t.Fatalf("expected the ACL to be populated, got %+v", objInfo)
+	}
+
+	// Without a version, the stat failure is still fatal.
+	stub.acls["/bucket/object?versionId="] = stub.acls["/bucket/object?versionId=v1"]
+	if _, err = clnt.GetObjectACL(ctx, "bucket", "object"); ToErrorResponse(err).StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestParseGrantHeader(t *testing.T) {
+	grants, err := ParseGrantHeader(PermissionRead, `id="abc", uri="`+GroupAllUsers+`",emailAddress="a,b@example.com"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, g := range grants {
+		if g.Permission != PermissionRead || g.Grantee.XMLXSI != g.Grantee.Type {
+			t.Fatalf("unexpected grant %+v", g)
+		}
+		keys = append(keys, g.Grantee.Type+":"+granteeKey(g.Grantee))
+	}
+	want := "CanonicalUser:id=abc,Group:uri=" + GroupAllUsers + ",AmazonCustomerByEmail:emailAddress=a,b@example.com"
+	if strings.Join(keys, ",") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(keys, ","))
+	}
+
+	// Round trip with the header form of the grants.
+	acle := &AccessControlPolicyEncode{}
+	acle.AccessControlList.Grants = grants
+	headers := getAmzGrantACL(policyEncodeToDecode(acle), false)
+	reparsed, err := ParseGrantHeader(PermissionRead, strings.Join(headers["X-Amz-Grant-Read"], ", "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reparsed, grants) {
+		t.Fatalf("expected %+v, got %+v", grants, reparsed)
+	}
+
+	for i, testCase := range []struct {
+		permission, value string
+	}{
+		{"READ", ""},
+		{"READ", `id=abc`},
+		{"READ", `id="abc`},
+		{"READ", `id="abc" uri="x"`},
+		{"READ", `id="abc",`},
+		{"READ", `id=""`},
+		{"READ", `name="abc"`},
+		{"READ", `"abc"`},
+		{"WRITE-ACP", `id="abc"`},
+	} {
+		if _, err := ParseGrantHeader(testCase.permission, testCase.value); ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("Test %d: expected InvalidArgument for %q, got %v", i+1, testCase.value, err)
+		}
+	}
+}
+
+func TestGetObjectACLMultipleOwners(t *testing.T) {
+	const dualOwnerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Owner><ID>first</ID><DisplayName>first</DisplayName></Owner><Owner><ID>second</ID></Owner><AccessControlList>` +
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>first</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>` +
+		`</AccessControlList></AccessControlPolicy>`
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = dualOwnerXML
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	_, err := clnt.GetObjectACL(context.Background(), "bucket", "object")
+	if !errors.Is(err, ErrMultipleACLOwners) || !strings.Contains(err.Error(), "2 Owner elements") {
+		t.Fatalf("expected ErrMultipleACLOwners, got %v", err)
+	}
+
+	var trace bytes.Buffer
+	clnt.TraceOn(&trace)
+	info, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{
+		ACLOptions: ACLOptions{AllowMultipleOwners: true},
+		SkipStat:   true,
+	})
+	clnt.TraceOff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Owner.ID != "first" || info.Owner.DisplayName != "first" {
+		t.Fatalf("expected the first owner, got %+v", info.Owner)
+	}
+	if !strings.Contains(trace.String(), "2 Owner elements in the ACL of bucket/object") {
+		t.Fatalf("expected a trace warning, got %s", trace.String())
+	}
+}
+
+func TestACLPolicyXMLMatchesStructDecode(t *testing.T) {
+	body := strings.Replace(testACLXML(
+		testUserGrantXML("owner", PermissionFullControl),
+		testGroupGrantXML(GroupAllUsers, PermissionRead),
+	), "</AccessControlPolicy>", `<VendorTag xmlns="urn:vendor" a="1"><Level>gold</Level></VendorTag></AccessControlPolicy>`, 1)
+
+	want := &AccessControlPolicyDecode{}
+	if err := xmlDecoder(strings.NewReader(body), want); err != nil {
+		t.Fatal(err)
+	}
+	got := aclPolicyXML{policy: &AccessControlPolicyDecode{}}
+	if err := decodeACLBody([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.owners) != 1 {
+		t.Fatalf("expected 1 owner, got %+v", got.owners)
+	}
+	got.policy.Owner = got.owners[0]
+	if !reflect.DeepEqual(got.policy, want) {
+		t.Fatalf("expected %+v, got %+v", want, got.policy)
+	}
+}
+
+func TestGetObjectCannedACL(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	owner := Owner{ID: "owner", DisplayName: "owner"}
+	bucketOwner := Owner{ID: "bucket-owner"}
+	opts := GetObjectACLOptions{BucketOwnerID: bucketOwner.ID}
+
+	for _, canned := range []string{"private", "public-read", "public-read-write", "authenticated-read", "bucket-owner-full-control"} {
+		acle, err := ExpandCannedACL(canned, owner, &bucketOwner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = clnt.PutObjectAcl(ctx, "bucket", canned, acle); err != nil {
+			t.Fatal(err)
+		}
+		got, err := clnt.GetObjectCannedACLWithOptions(ctx, "bucket", canned, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", canned, err)
+		}
+		if got != canned {
+			t.Errorf("expected canned ACL %s, got %s", canned, got)
+		}
+	}
+
+	// Without the bucket owner, bucket-owner-full-control is custom.
+	if _, err := clnt.GetObjectCannedACL(ctx, "bucket", "bucket-owner-full-control"); !errors.Is(err, ErrCustomACL) {
+		t.Fatalf("expected ErrCustomACL, got %v", err)
+	}
+
+	stub.acls["/bucket/custom?versionId="] = testACLXML(
+		testUserGrantXML("owner", PermissionFullControl),
+		testUserGrantXML("alice", PermissionWrite),
+	)
+	got, err := clnt.GetObjectCannedACLWithOptions(ctx, "bucket", "custom", opts)
+	if !errors.Is(err, ErrCustomACL) || got != "" {
+		t.Fatalf("expected ErrCustomACL, got %q, %v", got, err)
+	}
+}
+
+func TestGetObjectACLVersionID(t *testing.T) {
+	var sendVersion bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sendVersion {
+			w.Header().Set("X-Amz-Version-Id", "v2")
+		}
+		w.Write([]byte(testACLXML(testUserGrantXML("owner", PermissionFullControl))))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	testCases := []struct {
+		sendVersion bool
+		versionID   string
+		expected    string
+	}{
+		{true, "", "v2"},
+		{true, "v2", "v2"},
+		{false, "v1", "v1"},
+		{false, "", ""},
+	}
+	for i, testCase := range testCases {
+		sendVersion = testCase.sendVersion
+		info, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{
+			VersionID: testCase.versionID,
+			SkipStat:  true,
+		})
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if info.VersionID != testCase.expected {
+			t.Errorf("Test %d: expected version %q, got %q", i+1, testCase.expected, info.VersionID)
+		}
+	}
+}
+
+func TestGetObjectACLTruncatedResponse(t *testing.T) {
+	full := testACLXML(testUserGrantXML("owner", PermissionFullControl))
+	testCases := []struct {
+		body          string
+		contentLength int
+		truncated     bool
+		errContains   string
+	}{
+		// Truncated documents.
+		{full[:len(full)/2], 0, true, ""},
+		{full[:20], 0, true, ""},
+		// Connection closed before Content-Length bytes were sent.
+		{full[:len(full)/2], len(full), true, ""},
+		// Complete documents.
+		{`<ListBucketResult><Name>bucket</Name></ListBucketResult>`, 0, false, "unexpected root element <ListBucketResult>"},
+		{`<AccessControlPolicy><Owner></AccessControlPolicy>`, 0, false, "closed by"},
+	}
+
+	for i, testCase := range testCases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if testCase.contentLength > 0 {
+				w.Header().Set("Content-Length", strconv.Itoa(testCase.contentLength))
+			}
+			w.Write([]byte(testCase.body))
+		}))
+		clnt := newACLTestClient(t, srv)
+		_, err := clnt.GetObjectACL(context.Background(), "bucket", "object")
+		_, ownerErr := clnt.GetObjectOwner(context.Background(), "bucket", "object")
+		srv.Close()
+
+		for _, err := range []error{err, ownerErr} {
+			if err == nil {
+				t.Fatalf("Test %d: expected an error", i+1)
+			}
+			if errors.Is(err, ErrTruncatedACLResponse) != testCase.truncated {
+				t.Errorf("Test %d: expected truncated %v, got %v", i+1, testCase.truncated, err)
+			}
+			if !strings.Contains(err.Error(), testCase.errContains) {
+				t.Errorf("Test %d: expected an error containing %q, got %v", i+1, testCase.errContains, err)
+			}
+		}
+	}
+}
+
+func TestACLRequestError(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	_, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{VersionID: "v42"})
+	var reqErr *ACLRequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected an ACLRequestError, got %T %v", err, err)
+	}
+	if reqErr.Method != http.MethodGet || reqErr.Query.Get("versionId") != "v42" {
+		t.Fatalf("unexpected request error %+v", reqErr)
+	}
+	if !strings.Contains(err.Error(), "versionId=v42") || !strings.Contains(err.Error(), "acl=") {
+		t.Fatalf("expected the query in %q", err.Error())
+	}
+	if errResp := ToErrorResponse(err); errResp.Code != "NoSuchKey" || errResp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the wrapped NoSuchKey error, got %#v", errResp)
+	}
+
+	err = clnt.PutObjectACLstringWithOptions(context.Background(), "bucket", "missing", "<AccessControlPolicy/>", PutObjectACLOptions{
+		ACLOptions: ACLOptions{UsePOST: true},
+		VersionID:  "v7",
+	})
+	if !errors.As(err, &reqErr) || reqErr.Method != http.MethodPost || reqErr.Query.Get("versionId") != "v7" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ToErrorResponse(err).StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected the wrapped error response, got %v", err)
+	}
+}
+
+func TestGetObjectACLUnknownPermission(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(
+		testUserGrantXML("owner", PermissionFullControl),
+		testUserGrantXML("alice", "CUSTOM"),
+	)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	testCases := []struct {
+		mode    string
+		grants  int
+		canned  string
+		unknown int
+		err     error
+	}{
+		{"", 2, "", 0, nil},
+		{UnknownPermissionSkip, 2, "", 0, nil},
+		{UnknownPermissionKeep, 1, "private", 1, nil},
+		{UnknownPermissionError, 0, "", 0, ErrUnknownACLPermission},
+	}
+	for _, testCase := range testCases {
+		info, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{
+			SkipStat:          true,
+			UnknownPermission: testCase.mode,
+		})
+		if testCase.err != nil {
+			if !errors.Is(err, testCase.err) || !strings.Contains(err.Error(), "CUSTOM") {
+				t.Errorf("%q: expected %v, got %v", testCase.mode, testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", testCase.mode, err)
+		}
+		if len(info.Grant) != testCase.grants || info.Grant[0].Permission != PermissionFullControl {
+			t.Errorf("%q: unexpected grants %+v", testCase.mode, info.Grant)
+		}
+		if got := info.Metadata.Get("X-Amz-Acl"); got != testCase.canned {
+			t.Errorf("%q: expected canned ACL %q, got %q", testCase.mode, testCase.canned, got)
+		}
+		if testCase.canned == "" {
+			// The unknown permission has no X-Amz-Grant-* header.
+			want := []string{`id="owner"`}
+			if got := info.Metadata["X-Amz-Grant-Full-Control"]; !reflect.DeepEqual(got, want) || len(info.Metadata) != 1 {
+				t.Errorf("%q: expected only %v as grant metadata, got %v", testCase.mode, want, info.Metadata)
+			}
+		}
+		if len(info.UnknownGrants) != testCase.unknown {
+			t.Fatalf("%q: expected %d unknown grants, got %+v", testCase.mode, testCase.unknown, info.UnknownGrants)
+		}
+		if testCase.unknown > 0 && (info.UnknownGrants[0].Grantee.ID != "alice" || info.UnknownGrants[0].Permission != "CUSTOM") {
+			t.Errorf("%q: unexpected unknown grant %+v", testCase.mode, info.UnknownGrants[0])
+		}
+	}
+
+	_, err := clnt.GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{UnknownPermission: "ignore"})
+	if ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGetACLNotFound(t *testing.T) {
+	testCases := []struct {
+		code       string
+		withBody   bool
+		objectName string
+		sentinel   error
+		other      error
+	}{
+		{"NoSuchKey", true, "object", ErrNoSuchKey, ErrNoSuchBucket},
+		{"NoSuchKey", false, "object", ErrNoSuchKey, ErrNoSuchBucket},
+		{"NoSuchBucket", true, "object", ErrNoSuchBucket, ErrNoSuchKey},
+		{"NoSuchBucket", true, "", ErrNoSuchBucket, ErrNoSuchKey},
+		{"NoSuchBucket", false, "", ErrNoSuchBucket, ErrNoSuchKey},
+	}
+
+	for i, testCase := range testCases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			if testCase.withBody && r.Method != http.MethodHead {
+				w.Write(encodeResponse(ErrorResponse{Code: testCase.code}))
+			}
+		}))
+		clnt := newACLTestClient(t, srv)
+		var err error
+		if testCase.objectName == "" {
+			_, err = clnt.GetBucketACL(context.Background(), "bucket")
+		} else {
+			_, err = clnt.GetObjectACLWithOptions(context.Background(), "bucket", testCase.objectName, GetObjectACLOptions{SkipStat: true})
+		}
+		srv.Close()
+		if !errors.Is(err, testCase.sentinel) {
+			t.Errorf("Test %d: expected errors.Is(%v), got %v", i+1, testCase.sentinel, err)
+		}
+		if errors.Is(err, testCase.other) || errors.Is(err, ErrMalformedACL) {
+			t.Errorf("Test %d: unexpected match for %v", i+1, err)
+		}
+	}
+
+	// A denied request matches neither sentinel.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+	}))
+	defer srv.Close()
+	_, err := newACLTestClient(t, srv).GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{SkipStat: true})
+	if err == nil || errors.Is(err, ErrNoSuchKey) || errors.Is(err, ErrNoSuchBucket) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestReadACLBodyConcurrent(t *testing.T) {
+	stub := newACLTestServer()
+	var objects []string
+	for i := 0; i < 64; i++ {
+		object := "object-" + strconv.Itoa(i)
+		objects = append(objects, object)
+		stub.acls["/bucket/"+object+"?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("user-"+strconv.Itoa(i), "READ"))
+	}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	n := 0
+	for res := range newACLTestClient(t, srv).GetObjectsACL(context.Background(), "bucket", objects, BatchACLOptions{Concurrency: 16, SkipStat: true}) {
+		n++
+		if res.Err != nil {
+			t.Fatalf("%s: %v", res.Object, res.Err)
+		}
+		grants := res.Info.Grant
+		want := "user-" + strings.TrimPrefix(res.Object, "object-")
+		if len(grants) != 2 || grants[1].Grantee.ID != want {
+			t.Errorf("%s: expected a grant to %s, got %+v", res.Object, want, grants)
+		}
+	}
+	if n != len(objects) {
+		t.Fatalf("expected %d results, got %d", len(objects), n)
+	}
+}
+
+func BenchmarkReadACLBody(b *testing.B) {
+	body := []byte(testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("abc123", "READ"), testGroupGrantXML(GroupAllUsers, "READ")))
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, err := readACLBody(bytes.NewReader(body), MaxACLResponseSize)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err = decodeACLBody(buf.Bytes(), &AccessControlPolicyDecode{}); err != nil {
+				b.Fatal(err)
+			}
+			putACLBuffer(buf)
+		}
+	})
+
+	// Baseline reading every body into a fresh buffer.
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, err := ioutil.ReadAll(bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err = decodeACLBody(buf, &AccessControlPolicyDecode{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetACLEmptyPermission(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(
+		testUserGrantXML("owner", "FULL_CONTROL"),
+		`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>`+GroupAllUsers+`</URI></Grantee></Grant>`,
+		testUserGrantXML("abc123", "READ"),
+	)
+	stub.acls["/bucket/?versionId="] = stub.acls["/bucket/object?versionId="]
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	// Grants are numbered from 0, as in the validation errors.
+	if _, err := clnt.GetObjectACL(ctx, "bucket", "object"); !errors.Is(err, ErrEmptyACLPermission) || !strings.Contains(err.Error(), "grant 1 ") {
+		t.Fatalf("expected ErrEmptyACLPermission for grant 1, got %v", err)
+	}
+	if _, err := clnt.GetBucketACLInfo(ctx, "bucket"); !errors.Is(err, ErrEmptyACLPermission) {
+		t.Fatalf("expected ErrEmptyACLPermission, got %v", err)
+	}
+
+	info, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{
+		ACLOptions: ACLOptions{SkipEmptyPermission: true},
+		SkipStat:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Grant) != 2 || info.Grant[0].Grantee.ID != "owner" || info.Grant[1].Grantee.ID != "abc123" {
+		t.Fatalf("expected the permission-less grant to be dropped, got %+v", info.Grant)
+	}
+	if _, ok := info.Metadata["X-Amz-Grant-Read"]; !ok {
+		t.Fatalf("expected the remaining grants in the headers, got %v", info.Metadata)
+	}
+
+	acld, err := clnt.GetBucketACLWithOptions(ctx, "bucket", ACLOptions{SkipEmptyPermission: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acld.AccessControlList.Grants) != 2 || hasAllUsersGrant(acld, PermissionRead) {
+		t.Fatalf("unexpected grants %+v", acld.AccessControlList.Grants)
+	}
+}
+
+func TestGetObjectACLAcceptJSON(t *testing.T) {
+	acld := &AccessControlPolicyDecode{Owner: Owner{ID: "owner", DisplayName: "owner"}}
+	acld.AccessControlList.Grants = []GrantDecode{
+		{Grantee: GranteeDecode{Type: GranteeTypeCanonicalUser, ID: "owner"}, Permission: PermissionFullControl},
+		{Grantee: GranteeDecode{Type: GranteeTypeGroup, URI: GroupAllUsers}, Permission: PermissionRead},
+	}
+	jsonBody, err := ACLToJSON(acld)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, serveJSON := range []bool{true, false} {
+		var accept string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept = r.Header.Get("Accept")
+			if serveJSON {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Write(jsonBody)
+				return
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(testACLXML(testUserGrantXML("owner", "FULL_CONTROL"), testGroupGrantXML(GroupAllUsers, "READ"))))
+		}))
+		info, err := newACLTestClient(t, srv).GetObjectACLWithOptions(context.Background(), "bucket", "object", GetObjectACLOptions{
+			AcceptJSON: true,
+			SkipStat:   true,
+		})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("JSON %v: %v", serveJSON, err)
+		}
+		if accept != "application/json" {
+			t.Errorf("JSON %v: expected an Accept: application/json header, got %q", serveJSON, accept)
+		}
+		if info.Owner.ID != "owner" || len(info.Grant) != 2 {
+			t.Fatalf("JSON %v: unexpected owner %+v or grants %+v", serveJSON, info.Owner, info.Grant)
+		}
+		for i, typ := range []string{GranteeTypeCanonicalUser, GranteeTypeGroup} {
+			if g := info.Grant[i].Grantee; g.Type != typ || g.XMLXSI != typ {
+				t.Errorf("JSON %v: expected grantee %d of type %s, got %+v", serveJSON, i, typ, g)
+			}
+		}
+		if canned := info.Metadata.Get("X-Amz-Acl"); canned != "public-read" {
+			t.Errorf("JSON %v: expected the public-read canned ACL, got %q", serveJSON, canned)
+		}
+	}
+}
+
+func TestGetObjectACLNotAnACLResponse(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		body        string
+	}{
+		{"image/png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"},
+		{"text/plain", "hello, world\n"},
+		{"", "plain object body"},
+		{"application/xml", `<?xml version="1.0"?><catalog><book id="1"/></catalog>`},
+	}
+
+	for i, testCase := range testCases {
+		// The proxy stripped ?acl, the object itself is served.
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if testCase.contentType != "" {
+				w.Header().Set("Content-Type", testCase.contentType)
+			} else {
+				w.Header()["Content-Type"] = nil
+			}
+			w.Header().Set("ETag", `"etag"`)
+			w.Write([]byte(testCase.body))
+		}))
+		clnt := newACLTestClient(t, srv)
+		_, err := clnt.GetObjectACL(context.Background(), "bucket", "object")
+		_, rawErr := clnt.GetObjectACLstring(context.Background(), "bucket", "object")
+		srv.Close()
+		if !errors.Is(err, ErrNotAnACLResponse) {
+			t.Errorf("Test %d: expected ErrNotAnACLResponse, got %v", i+1, err)
+		}
+		if !errors.Is(rawErr, ErrNotAnACLResponse) && testCase.contentType != "application/xml" {
+			t.Errorf("Test %d: expected ErrNotAnACLResponse for the raw ACL, got %v", i+1, rawErr)
+		}
+		if errors.Is(err, ErrTruncatedACLResponse) {
+			t.Errorf("Test %d: unexpected truncation error %v", i+1, err)
+		}
+	}
+}
@@ -94,6 +94,42 @@ func (c *Client) PresignedPutObject(ctx context.Context, bucketName string, obje
 	return c.presignURL(ctx, http.MethodPut, bucketName, objectName, expires, nil, nil)
 }
 
+// PresignGetObjectACL - Returns a presigned URL to read the ACL of an
+// object without credentials. URL can have a maximum expiry of upto
+// 7days or a minimum of 1sec.
+func (c *Client) PresignGetObjectACL(ctx context.Context, bucketName, objectName string, expires time.Duration) (u *url.URL, err error) {
+	if err = s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, err
+	}
+	urlValues := make(url.Values)
+	urlValues.Set("acl", "")
+	return c.presignURL(ctx, http.MethodGet, bucketName, objectName, expires, urlValues, nil)
+}
+
+// PresignPutObjectACL - Returns a presigned URL to set the ACL of an
+// object to the given AccessControlPolicy XML document without
+// credentials. Presigned URLs leave the payload unsigned, so the
+// Content-MD5 of the document is signed instead: the request must send
+// the document along with the returned headers. URL can have a maximum
+// expiry of upto 7days or a minimum of 1sec. Signature V2 is not
+// supported.
+func (c *Client) PresignPutObjectACL(ctx context.Context, bucketName, objectName string, expires time.Duration, acl string) (u *url.URL, header http.Header, err error) {
+	if err = s3utils.CheckValidObjectName(objectName); err != nil {
+		return nil, nil, err
+	}
+	if acl == "" {
+		return nil, nil, errInvalidArgument("ACL policy cannot be empty.")
+	}
+	urlValues := make(url.Values)
+	urlValues.Set("acl", "")
+	header = make(http.Header)
+	header.Set("Content-Md5", sumMD5Base64([]byte(acl)))
+	if u, err = c.presignURL(ctx, http.MethodPut, bucketName, objectName, expires, urlValues, header); err != nil {
+		return nil, nil, err
+	}
+	return u, header, nil
+}
+
 // PresignHeader - similar to Presign() but allows including HTTP headers that
 // will be used to build the signature. The request using the resulting URL will
 // need to have the exact same headers to be added for signature validation to
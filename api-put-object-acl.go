@@ -0,0 +1,447 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// GranteeEncode is the grantee of an ACL grant to be sent to the server.
+// XMLNS and XMLXSI are emitted as the xmlns:xsi and xsi:type attributes
+// of the Grantee element.
+type GranteeEncode struct {
+	XMLName     xml.Name `xml:"Grantee" json:"-"`
+	XMLNS       string   `xml:"xmlns:xsi,attr,omitempty" json:"-"`
+	XMLXSI      string   `xml:"xsi:type,attr,omitempty" json:"-"`
+	Type        string   `xml:"-" json:"type"`
+	ID          string   `xml:"ID,omitempty" json:"id,omitempty"`
+	DisplayName string   `xml:"DisplayName,omitempty" json:"displayName,omitempty"`
+	URI         string   `xml:"URI,omitempty" json:"uri,omitempty"`
+	Email       string   `xml:"EmailAddress,omitempty" json:"email,omitempty"`
+}
+
+// GrantEncode holds a single ACL grant to be sent to the server.
+type GrantEncode struct {
+	XMLName    xml.Name      `xml:"Grant" json:"-"`
+	Grantee    GranteeEncode `xml:"Grantee" json:"grantee"`
+	Permission string        `xml:"Permission" json:"permission"`
+}
+
+// AccessControlListEncode contains the grants of an ACL to be sent to the server.
+type AccessControlListEncode struct {
+	XMLName xml.Name      `xml:"AccessControlList" json:"-"`
+	Grants  []GrantEncode `xml:"Grant" json:"grants"`
+}
+
+// AccessControlPolicyEncode is the AccessControlPolicy document
+// sent with PUT ?acl, in the S3 namespace. Extensions are sent after the
+// access control list.
+type AccessControlPolicyEncode struct {
+	XMLName           xml.Name                `xml:"http://s3.amazonaws.com/doc/2006-03-01/ AccessControlPolicy" json:"-"`
+	Owner             Owner                   `xml:"Owner" json:"owner"`
+	AccessControlList AccessControlListEncode `xml:"AccessControlList" json:"accessControlList"`
+	Extensions        []ACLExtension          `xml:",any" json:"extensions,omitempty"`
+}
+
+// PutObjectACLOptions holds options for PutObjectACLWithOptions.
+type PutObjectACLOptions struct {
+	ACLOptions
+
+	// VersionID selects the object version whose ACL is set.
+	VersionID string
+
+	// FillOwner fetches the current owner of the object and uses it
+	// when the Owner of the policy is left empty.
+	FillOwner bool
+
+	// Normalize de-duplicates and sorts the grants before sending the
+	// policy, see AccessControlPolicyEncode.Normalize. The policy
+	// passed by the caller is not modified.
+	Normalize bool
+	// CoalesceFullControl is passed to Normalize.
+	CoalesceFullControl bool
+
+	// OnACLApplied, when set, is called with the sent policy after a
+	// successful PutObjectACLWithOptions call. It is not called by the
+	// raw string variant.
+	OnACLApplied func(bucketName, objectName string, policy *AccessControlPolicyEncode)
+
+	// RequestPayer acknowledges that the requester is charged for the
+	// request, which requester-pays buckets require.
+	RequestPayer bool
+
+	// DryRun validates and marshals the policy without sending it, see
+	// PutObjectACLWithResult. FillOwner still reads the current ACL.
+	DryRun bool
+
+	// AutoCorrectOwner handles servers rejecting a policy whose Owner
+	// does not match the owner of the object, e.g. a policy copied from
+	// another account: when the update is denied, the current owner is
+	// fetched and, if it differs, the policy is sent once more with
+	// that owner.
+	AutoCorrectOwner bool
+}
+
+// PutObjectACLResult is the result of PutObjectACLWithResult.
+type PutObjectACLResult struct {
+	// Body is the AccessControlPolicy document sent, or that would have
+	// been sent with DryRun.
+	Body []byte
+
+	// DryRun reports that the document was not sent.
+	DryRun bool
+}
+
+// putACL executes PUT ?acl, or POST ?acl when opts.UsePOST is set, on a
+// bucket, or on an object when objectName is non-empty, with the given
+// XML document as body. An empty acl sends
+// no body, the ACL being then carried by customHeader.
+func (c *Client) putACL(ctx context.Context, bucketName, objectName, versionID string, customHeader http.Header, acl string, opts ACLOptions) (err error) {
+	customHeader, err = opts.setHeaders(customHeader)
+	if err != nil {
+		return err
+	}
+	if err = checkACLHeaders(customHeader); err != nil {
+		return err
+	}
+
+	urlValues := make(url.Values)
+	urlValues.Set("acl", "")
+	if versionID != "" {
+		urlValues.Set("versionId", versionID)
+	}
+
+	reqMetadata := requestMetadata{
+		bucketName:   bucketName,
+		objectName:   objectName,
+		queryValues:  urlValues,
+		customHeader: customHeader,
+	}
+	if acl != "" {
+		reqBytes := []byte(acl)
+		reqMetadata.contentBody = bytes.NewReader(reqBytes)
+		reqMetadata.contentLength = int64(len(reqBytes))
+		if opts.Chunked {
+			// Unknown length, the body is sent with chunked transfer encoding.
+			reqMetadata.contentLength = -1
+		}
+		if !opts.DisableContentMD5 {
+			reqMetadata.contentMD5Base64 = sumMD5Base64(reqBytes)
+		}
+	} else {
+		reqMetadata.contentSHA256Hex = emptySHA256Hex
+	}
+	opts.setRetry(&reqMetadata)
+
+	// Execute PUT to set the ACL.
+	method := opts.putMethod()
+	start := time.Now()
+	resp, err := c.executeMethod(ctx, method, reqMetadata)
+	defer closeResponse(resp)
+	if opts.OnComplete != nil {
+		defer func() {
+			opts.onComplete(method, reqMetadata, resp, int64(len(acl)), start, err)
+		}()
+	}
+	if objectName == "" && c.bucketACLCache != nil {
+		c.bucketACLCache.InvalidateBucket(bucketName)
+	}
+	if err != nil {
+		return newACLRequestError(method, reqMetadata, err)
+	}
+	if resp != nil {
+		// S3 compatible servers reply either "200 OK" or "204 No Content".
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			return newACLRequestError(method, reqMetadata, httpRespToErrorResponse(resp, bucketName, objectName))
+		}
+	}
+	return nil
+}
+
+// objectCannedACLs is the set of canned ACLs valid on objects.
+var objectCannedACLs = map[string]bool{
+	"private":                   true,
+	"public-read":               true,
+	"public-read-write":         true,
+	"authenticated-read":        true,
+	"bucket-owner-read":         true,
+	"bucket-owner-full-control": true,
+}
+
+// PutObjectACLCanned sets a canned ACL on an object through the
+// x-amz-acl header, without an XML body.
+func (c *Client) PutObjectACLCanned(ctx context.Context, bucketName, objectName, cannedACL string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	if !objectCannedACLs[cannedACL] {
+		return errInvalidArgument("Unsupported canned ACL " + cannedACL + ".")
+	}
+	customHeader := make(http.Header)
+	customHeader.Set("x-amz-acl", cannedACL)
+	return c.putACL(ctx, bucketName, objectName, "", customHeader, "", ACLOptions{})
+}
+
+// checkACLHeaders rejects a canned ACL combined with grant headers,
+// which S3 refuses with InvalidRequest.
+func checkACLHeaders(customHeader http.Header) error {
+	cannedACL := customHeader.Get("X-Amz-Acl")
+	if cannedACL == "" {
+		return nil
+	}
+	var conflicts []string
+	for k := range customHeader {
+		if amzGrantHeaders[http.CanonicalHeaderKey(k)] {
+			conflicts = append(conflicts, http.CanonicalHeaderKey(k))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+	return errInvalidArgument(fmt.Sprintf("Canned ACL %q cannot be combined with the %s header(s).",
+		cannedACL, strings.Join(conflicts, ", ")))
+}
+
+// amzGrantHeaders is the set of headers granting a permission.
+var amzGrantHeaders = map[string]bool{
+	"X-Amz-Grant-Read":         true,
+	"X-Amz-Grant-Write":        true,
+	"X-Amz-Grant-Read-Acp":     true,
+	"X-Amz-Grant-Write-Acp":    true,
+	"X-Amz-Grant-Full-Control": true,
+}
+
+// PutObjectACLGrants sets the ACL of an object through X-Amz-Grant-*
+// headers, without an XML body. grants maps a header name to its
+// grantees, each formatted as id="...", uri="..." or emailAddress="...",
+// which is the form of the ACL metadata returned by GetObjectACL. An
+// X-Amz-Acl key holding a canned ACL is accepted as well, but cannot be
+// combined with grant headers.
+func (c *Client) PutObjectACLGrants(ctx context.Context, bucketName, objectName string, grants map[string][]string) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	if len(grants) == 0 {
+		return errInvalidArgument("No ACL grant header given.")
+	}
+
+	customHeader := make(http.Header)
+	for k, v := range grants {
+		key := http.CanonicalHeaderKey(k)
+		switch {
+		case key == "X-Amz-Acl":
+			if len(v) != 1 || !objectCannedACLs[v[0]] {
+				return errInvalidArgument(fmt.Sprintf("Unsupported canned ACL %q.", strings.Join(v, ", ")))
+			}
+		case amzGrantHeaders[key]:
+			if len(v) == 0 {
+				return errInvalidArgument("No grantee given for " + key + ".")
+			}
+		default:
+			return errInvalidArgument(fmt.Sprintf("Invalid ACL grant header %q.", k))
+		}
+		customHeader.Set(key, strings.Join(v, ", "))
+	}
+	return c.putACL(ctx, bucketName, objectName, "", customHeader, "", ACLOptions{})
+}
+
+// ValidateACLXML checks that s is valid UTF-8 and a well-formed XML
+// document whose root element is AccessControlPolicy, e.g. to catch an
+// unescaped "&" or "<" in a hand-built document before sending it.
+func ValidateACLXML(s string) error {
+	if !utf8.ValidString(s) {
+		return errInvalidArgument("ACL document is not valid UTF-8.")
+	}
+	d := xml.NewDecoder(strings.NewReader(s))
+	depth, roots := 0, 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errInvalidArgument(fmt.Sprintf("ACL document is not well-formed XML: %v.", err))
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if roots++; roots > 1 {
+					return errInvalidArgument("ACL document has more than one root element.")
+				}
+				if t.Name.Local != "AccessControlPolicy" {
+					return errInvalidArgument(fmt.Sprintf("ACL document root element is <%s>, expected <AccessControlPolicy>.", t.Name.Local))
+				}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if roots == 0 {
+		return errInvalidArgument("ACL document has no AccessControlPolicy element.")
+	}
+	return nil
+}
+
+// PutObjectACLstring sets the ACL of an object from a raw
+// AccessControlPolicy XML document, checked with ValidateACLXML.
+func (c *Client) PutObjectACLstring(ctx context.Context, bucketName, objectName, acl string) error {
+	return c.PutObjectACLstringWithOptions(ctx, bucketName, objectName, acl, PutObjectACLOptions{})
+}
+
+// PutObjectACLstringWithOptions sets the ACL of an object from a raw
+// AccessControlPolicy XML document with options.
+func (c *Client) PutObjectACLstringWithOptions(ctx context.Context, bucketName, objectName, acl string, opts PutObjectACLOptions) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+
+	if err := ValidateACLXML(acl); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		_, err := opts.setHeaders(nil)
+		return err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	return c.putACL(ctx, bucketName, objectName, opts.VersionID, requestPayerHeader(nil, opts.RequestPayer), acl, opts.ACLOptions)
+}
+
+// PutObjectAcl sets the ACL of an object.
+func (c *Client) PutObjectAcl(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode) error {
+	return c.PutObjectACLWithOptions(ctx, bucketName, objectName, acle, PutObjectACLOptions{})
+}
+
+// PutObjectACLWithOptions sets the ACL of an object with options.
+func (c *Client) PutObjectACLWithOptions(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode, opts PutObjectACLOptions) error {
+	_, err := c.PutObjectACLWithResult(ctx, bucketName, objectName, acle, opts)
+	return err
+}
+
+// PutObjectACLWithResult is PutObjectACLWithOptions also returning the
+// marshaled policy. With opts.DryRun the policy is validated and
+// marshaled but not sent, e.g. to review the document in CI.
+func (c *Client) PutObjectACLWithResult(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode, opts PutObjectACLOptions) (PutObjectACLResult, error) {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return PutObjectACLResult{}, err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return PutObjectACLResult{}, err
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	if acle == nil {
+		return PutObjectACLResult{}, errInvalidArgument("ACL policy cannot be nil.")
+	}
+	if err := acle.validate(); err != nil {
+		return PutObjectACLResult{}, err
+	}
+	if opts.FillOwner && acle.Owner.ID == "" && acle.Owner.DisplayName == "" {
+		acld, err := c.getACLPolicy(ctx, bucketName, objectName, opts.VersionID, requestPayerHeader(nil, opts.RequestPayer), opts.ACLOptions)
+		if err != nil {
+			return PutObjectACLResult{}, err
+		}
+		filled := *acle
+		filled.Owner = Owner{ID: acld.Owner.ID, DisplayName: acld.Owner.DisplayName}
+		acle = &filled
+	}
+	if opts.Normalize {
+		normalized := *acle
+		normalized.AccessControlList.Grants = append([]GrantEncode(nil), acle.AccessControlList.Grants...)
+		normalized.Normalize(opts.CoalesceFullControl)
+		acle = &normalized
+	}
+	aclBytes, err := opts.marshalPolicy(acle)
+	if err != nil {
+		return PutObjectACLResult{}, err
+	}
+	result := PutObjectACLResult{Body: aclBytes, DryRun: opts.DryRun}
+	if opts.DryRun {
+		if _, err = opts.setHeaders(nil); err != nil {
+			return PutObjectACLResult{}, err
+		}
+		return result, nil
+	}
+	err = c.PutObjectACLstringWithOptions(ctx, bucketName, objectName, string(aclBytes), opts)
+	if err != nil && opts.AutoCorrectOwner && ToErrorResponse(err).Code == "AccessDenied" {
+		if corrected := c.correctACLOwner(ctx, bucketName, objectName, acle, opts); corrected != nil {
+			acle = corrected
+			if result.Body, err = opts.marshalPolicy(acle); err != nil {
+				return PutObjectACLResult{}, err
+			}
+			err = c.PutObjectACLstringWithOptions(ctx, bucketName, objectName, string(result.Body), opts)
+		}
+	}
+	if err != nil {
+		return PutObjectACLResult{}, err
+	}
+	if opts.OnACLApplied != nil {
+		opts.OnACLApplied(bucketName, objectName, acle)
+	}
+	return result, nil
+}
+
+// correctACLOwner returns a copy of acle carrying the current owner of
+// the object, or nil when the owner cannot be read or already matches.
+func (c *Client) correctACLOwner(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode, opts PutObjectACLOptions) *AccessControlPolicyEncode {
+	acld, err := c.getACLPolicy(ctx, bucketName, objectName, opts.VersionID, requestPayerHeader(nil, opts.RequestPayer), opts.ACLOptions)
+	if err != nil || acld.Owner.ID == acle.Owner.ID {
+		return nil
+	}
+	corrected := *acle
+	corrected.Owner = Owner{ID: acld.Owner.ID, DisplayName: acld.Owner.DisplayName}
+	return &corrected
+}
+
+// PutObjectACLAndGet sets the ACL of an object then reads it back,
+// returning the ACL as materialized by the server.
+func (c *Client) PutObjectACLAndGet(ctx context.Context, bucketName, objectName string, acle *AccessControlPolicyEncode) (*ObjectInfo, error) {
+	if err := c.PutObjectAcl(ctx, bucketName, objectName, acle); err != nil {
+		return nil, err
+	}
+	return c.GetObjectACL(ctx, bucketName, objectName)
+}
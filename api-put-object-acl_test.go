@@ -0,0 +1,1048 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// aclTestServer is an in-memory S3 stub serving GET/PUT ?acl and HEAD
+// object requests. ACLs are stored per path and version ID.
+type aclTestServer struct {
+	mu   sync.Mutex
+	acls map[string]string
+	reqs []*http.Request
+
+	// owner is returned by ListBuckets requests.
+	owner Owner
+}
+
+func newACLTestServer() *aclTestServer {
+	return &aclTestServer{acls: make(map[string]string)}
+}
+
+func (s *aclTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reqs = append(s.reqs, r)
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.Header().Set("Content-Length", "0")
+		return
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/" {
+		w.Write(encodeResponse(listAllMyBucketsResult{Owner: owner{ID: s.owner.ID, DisplayName: s.owner.DisplayName}}))
+		return
+	}
+	if _, ok := r.URL.Query()["acl"]; !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	key := r.URL.Path + "?versionId=" + r.URL.Query().Get("versionId")
+	switch r.Method {
+	case http.MethodGet:
+		acl, ok := s.acls[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(encodeResponse(ErrorResponse{Code: "NoSuchKey", Message: "The specified key does not exist."}))
+			return
+		}
+		w.Write([]byte(acl))
+	case http.MethodPut:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.acls[key] = string(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// count returns the number of received requests with the given method.
+func (s *aclTestServer) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.reqs {
+		if r.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPutObjectAclEmailRoundTrip(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	acle := &AccessControlPolicyEncode{
+		Owner: Owner{ID: "owner-id", DisplayName: "owner"},
+		AccessControlList: AccessControlListEncode{
+			Grants: []GrantEncode{{
+				Grantee: GranteeEncode{
+					XMLNS:  "http://www.w3.org/2001/XMLSchema-instance",
+					XMLXSI: "AmazonCustomerByEmail",
+					Type:   "AmazonCustomerByEmail",
+					Email:  "user@example.com",
+				},
+				Permission: "READ",
+			}},
+		},
+	}
+
+	buf, err := xml.Marshal(acle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="AmazonCustomerByEmail"><EmailAddress>user@example.com</EmailAddress></Grantee>`
+	if !bytes.Contains(buf, []byte(want)) {
+		t.Fatalf("marshaled ACL %s does not contain %s", buf, want)
+	}
+
+	if err = clnt.PutObjectAcl(context.Background(), "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := clnt.GetObjectACL(context.Background(), "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objInfo.Grant) != 1 {
+		t.Fatalf("expected 1 grant, got %d", len(objInfo.Grant))
+	}
+	grantee := objInfo.Grant[0].Grantee
+	if grantee.Email != "user@example.com" || grantee.Type != "AmazonCustomerByEmail" {
+		t.Fatalf("email grantee did not survive the round trip: %+v", grantee)
+	}
+}
+
+func TestPutObjectACLVersionID(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	current := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", "FULL_CONTROL").Build()
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", current); err != nil {
+		t.Fatal(err)
+	}
+	old := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", "FULL_CONTROL").
+		GrantGroup("http://acs.amazonaws.com/groups/global/AllUsers", "READ").
+		Build()
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", old, PutObjectACLOptions{VersionID: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	objInfo, err := clnt.GetObjectACL(ctx, "bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objInfo.Grant) != 1 {
+		t.Fatalf("expected current version to keep 1 grant, got %d", len(objInfo.Grant))
+	}
+
+	objInfo, err = clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{VersionID: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objInfo.Grant) != 2 {
+		t.Fatalf("expected version v1 to have 2 grants, got %d", len(objInfo.Grant))
+	}
+
+	for _, r := range stub.reqs {
+		if r.Method == http.MethodHead {
+			continue
+		}
+		if _, ok := r.URL.Query()["acl"]; !ok {
+			t.Errorf("request %s %s is missing the acl query", r.Method, r.URL)
+		}
+	}
+}
+
+func TestPutObjectACLCanned(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if _, ok := r.URL.Query()["acl"]; !ok || r.Method != http.MethodPut {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if got := r.Header.Get("x-amz-acl"); got != "public-read" {
+			t.Errorf("expected x-amz-acl %q, got %q", "public-read", got)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); len(body) != 0 {
+			t.Errorf("expected an empty body, got %q", body)
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	if err := clnt.PutObjectACLCanned(context.Background(), "bucket", "object", "public-read"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := clnt.PutObjectACLCanned(context.Background(), "bucket", "object", "log-delivery-write")
+	if errResp := ToErrorResponse(err); errResp.Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestPutACLStatusCodes(t *testing.T) {
+	testCases := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusOK, ""},
+		{http.StatusNoContent, ""},
+		{http.StatusBadRequest, "MalformedACLError"},
+		{http.StatusForbidden, "AccessDenied"},
+	}
+
+	for i, testCase := range testCases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testCase.status)
+			if testCase.code != "" {
+				w.Write(encodeResponse(ErrorResponse{Code: testCase.code}))
+			}
+		}))
+		clnt := newACLTestClient(t, srv)
+		acl := `<AccessControlPolicy></AccessControlPolicy>`
+
+		errs := []error{
+			clnt.PutObjectACLstring(context.Background(), "bucket", "object", acl),
+			clnt.PutBucketACLstring(context.Background(), "bucket", acl),
+			clnt.PutObjectAcl(context.Background(), "bucket", "object", &AccessControlPolicyEncode{}),
+			clnt.PutBucketAcl(context.Background(), "bucket", &AccessControlPolicyEncode{}),
+		}
+		for j, err := range errs {
+			if testCase.code == "" && err != nil {
+				t.Errorf("Test %d.%d: expected no error, got %v", i+1, j+1, err)
+			}
+			if testCase.code != "" && ToErrorResponse(err).Code != testCase.code {
+				t.Errorf("Test %d.%d: expected %s, got %v", i+1, j+1, testCase.code, err)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func TestPutObjectAclValidation(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	valid := GrantEncode{Grantee: GranteeEncode{Type: "CanonicalUser", ID: "abc123"}, Permission: "READ"}
+	testCases := []struct {
+		grant GrantEncode
+		msg   string
+	}{
+		// Test 1: misspelled permission.
+		{GrantEncode{Grantee: valid.Grantee, Permission: "READ_ACL"}, `Invalid permission "READ_ACL" in grant 1.`},
+		// Test 2: empty permission.
+		{GrantEncode{Grantee: valid.Grantee}, `Invalid permission "" in grant 1.`},
+		// Test 3: unknown grantee type.
+		{GrantEncode{Grantee: GranteeEncode{Type: "User", ID: "abc123"}, Permission: "READ"}, `Invalid grantee type "User" in grant 1.`},
+		// Test 4: missing grantee type.
+		{GrantEncode{Grantee: GranteeEncode{ID: "abc123"}, Permission: "READ"}, `Invalid grantee type "" in grant 1.`},
+		// Test 5: display name left unresolved by Build.
+		{NewACLBuilder(Owner{}).GrantDisplayName("alice", "READ").Build().AccessControlList.Grants[0], `CanonicalUser grantee of grant 1 has no ID.`},
+		// Test 6: group without URI.
+		{GrantEncode{Grantee: GranteeEncode{Type: "Group"}, Permission: "READ"}, `Group grantee of grant 1 has no URI.`},
+		// Test 7: email grantee without email address.
+		{GrantEncode{Grantee: GranteeEncode{Type: "AmazonCustomerByEmail", ID: "abc123"}, Permission: "READ"}, `AmazonCustomerByEmail grantee of grant 1 has no email address.`},
+	}
+
+	for i, testCase := range testCases {
+		acle := &AccessControlPolicyEncode{
+			AccessControlList: AccessControlListEncode{Grants: []GrantEncode{valid, testCase.grant}},
+		}
+		for _, err := range []error{
+			clnt.PutObjectAcl(context.Background(), "bucket", "object", acle),
+			clnt.PutBucketAcl(context.Background(), "bucket", acle),
+		} {
+			errResp := ToErrorResponse(err)
+			if errResp.Code != "InvalidArgument" || errResp.Message != testCase.msg {
+				t.Errorf("Test %d: expected %q, got %v", i+1, testCase.msg, err)
+			}
+		}
+	}
+	if requests != 0 {
+		t.Fatalf("expected no request, got %d", requests)
+	}
+}
+
+func TestPutObjectAclFillOwner(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	owner := Owner{ID: "owner-id", DisplayName: "owner"}
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", NewACLBuilder(owner).GrantCanonicalUser("owner-id", "FULL_CONTROL").Build()); err != nil {
+		t.Fatal(err)
+	}
+
+	acle := NewACLBuilder(Owner{}).
+		GrantCanonicalUser("owner-id", "FULL_CONTROL").
+		GrantCanonicalUser("abc123", "READ").
+		Build()
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, PutObjectACLOptions{FillOwner: true}); err != nil {
+		t.Fatal(err)
+	}
+	if acle.Owner != (Owner{}) {
+		t.Fatalf("caller policy was modified: %+v", acle.Owner)
+	}
+
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acld.Owner.ID != owner.ID || acld.Owner.DisplayName != owner.DisplayName {
+		t.Fatalf("expected owner %+v, got %+v", owner, acld.Owner)
+	}
+	if len(acld.AccessControlList.Grants) != 2 {
+		t.Fatalf("expected 2 grants, got %d", len(acld.AccessControlList.Grants))
+	}
+
+	// No extra GET when the owner is already set.
+	gets := stub.count(http.MethodGet)
+	acle.Owner = owner
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, PutObjectACLOptions{FillOwner: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count(http.MethodGet); n != gets {
+		t.Fatalf("expected no GET request, got %d", n-gets)
+	}
+}
+
+func TestACLErrorRequestIDs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amz-request-id", "4442587FB7D0A2F9")
+		w.Header().Set("x-amz-id-2", "wBvgOe2gW0JC3tdzrPL2fFNq0C8oQ1pSTkBVWhzXP0")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(encodeResponse(ErrorResponse{
+			Code:    "MalformedACLError",
+			Message: "The XML you provided was not well-formed or did not validate against our published schema.",
+		}))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	_, getErr := clnt.GetObjectACL(ctx, "bucket", "object")
+	_, getBucketErr := clnt.GetBucketACL(ctx, "bucket")
+	for i, err := range []error{
+		clnt.PutObjectACLstring(ctx, "bucket", "object", "<AccessControlPolicy/>"),
+		clnt.PutBucketACLstring(ctx, "bucket", "<AccessControlPolicy/>"),
+		getErr,
+		getBucketErr,
+	} {
+		errResp := ToErrorResponse(err)
+		if errResp.RequestID != "4442587FB7D0A2F9" || errResp.HostID != "wBvgOe2gW0JC3tdzrPL2fFNq0C8oQ1pSTkBVWhzXP0" {
+			t.Errorf("Test %d: request IDs not propagated: %#v", i+1, err)
+		}
+		if errResp.StatusCode != http.StatusBadRequest || errResp.Code != "MalformedACLError" || errResp.Message == "" {
+			t.Errorf("Test %d: error body not preserved: %#v", i+1, err)
+		}
+	}
+}
+
+func TestPutObjectAclNormalize(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", "FULL_CONTROL").
+		GrantCanonicalUser("abc123", "READ").
+		GrantCanonicalUser("abc123", "READ").
+		Build()
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, PutObjectACLOptions{Normalize: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(acle.AccessControlList.Grants) != 3 {
+		t.Fatalf("caller policy was modified: %+v", acle.AccessControlList.Grants)
+	}
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grants := acld.AccessControlList.Grants; len(grants) != 2 || grants[0].Grantee.ID != "abc123" || grants[1].Grantee.ID != "owner" {
+		t.Fatalf("unexpected normalized grants %+v", grants)
+	}
+}
+
+func TestACLTraceHooks(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	var trace bytes.Buffer
+	clnt.TraceOn(&trace)
+	defer clnt.TraceOff()
+
+	var applied int
+	opts := PutObjectACLOptions{
+		OnACLApplied: func(bucketName, objectName string, policy *AccessControlPolicyEncode) {
+			applied++
+			if bucketName != "bucket" || objectName != "object" || len(policy.AccessControlList.Grants) != 1 {
+				t.Errorf("unexpected callback arguments %s %s %+v", bucketName, objectName, policy)
+			}
+		},
+	}
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", "FULL_CONTROL").Build()
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, opts); err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Fatalf("expected 1 callback, got %d", applied)
+	}
+
+	// Failed calls never trigger the callback.
+	acle.AccessControlList.Grants[0].Permission = "READ_ACL"
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, opts); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	acle.AccessControlList.Grants[0].Permission = "READ"
+	denySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+	}))
+	defer denySrv.Close()
+	if err := newACLTestClient(t, denySrv).PutObjectACLWithOptions(ctx, "bucket", "object", acle, opts); err == nil {
+		t.Fatal("expected an AccessDenied error")
+	}
+	if applied != 1 {
+		t.Fatalf("expected the callback to fire once, got %d", applied)
+	}
+
+	if !strings.Contains(trace.String(), "PUT /bucket/object?acl=") {
+		t.Fatalf("PUT ACL request was not traced:\n%s", trace.String())
+	}
+}
+
+func TestGetObjectACLTrace(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", "FULL_CONTROL").Build()); err != nil {
+		t.Fatal(err)
+	}
+
+	var traced *AccessControlPolicyDecode
+	gets := stub.count(http.MethodGet)
+	_, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{
+		SkipStat: true,
+		ACLTrace: func(bucketName, objectName string, policy *AccessControlPolicyDecode) {
+			traced = policy
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traced == nil || traced.Owner.ID != "owner" {
+		t.Fatalf("unexpected traced policy %+v", traced)
+	}
+	if n := stub.count(http.MethodGet) - gets; n != 1 {
+		t.Fatalf("expected a single GET, got %d", n)
+	}
+}
+
+func TestPutACLMalformedError(t *testing.T) {
+	testCases := []struct {
+		status    int
+		code      string
+		malformed bool
+	}{
+		{http.StatusBadRequest, "MalformedACLError", true},
+		{http.StatusForbidden, "AccessDenied", false},
+		{http.StatusNotFound, "NoSuchKey", false},
+	}
+
+	for i, testCase := range testCases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testCase.status)
+			w.Write(encodeResponse(ErrorResponse{Code: testCase.code}))
+		}))
+		err := newACLTestClient(t, srv).PutObjectACLstring(context.Background(), "bucket", "object", "<AccessControlPolicy/>")
+		srv.Close()
+
+		if errors.Is(err, ErrMalformedACL) != testCase.malformed {
+			t.Errorf("Test %d: expected errors.Is(ErrMalformedACL) = %t for %v", i+1, testCase.malformed, err)
+		}
+		var errResp ErrorResponse
+		if !errors.As(err, &errResp) || errResp.Code != testCase.code {
+			t.Errorf("Test %d: expected an ErrorResponse with code %s, got %#v", i+1, testCase.code, err)
+		}
+		if ToErrorResponse(err).StatusCode != testCase.status {
+			t.Errorf("Test %d: expected status %d, got %#v", i+1, testCase.status, err)
+		}
+	}
+}
+
+func TestACLRequestPayer(t *testing.T) {
+	for _, requestPayer := range []bool{false, true} {
+		stub := newACLTestServer()
+		srv := httptest.NewServer(stub)
+		clnt := newACLTestClient(t, srv)
+		ctx := context.Background()
+
+		acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+		if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", acle, PutObjectACLOptions{RequestPayer: requestPayer}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "object", GetObjectACLOptions{RequestPayer: requestPayer}); err != nil {
+			t.Fatal(err)
+		}
+		err := clnt.UpdateObjectACL(ctx, "bucket", "object", func(*AccessControlPolicyEncode) error {
+			return nil
+		}, UpdateACLOptions{RequestPayer: requestPayer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+
+		want := ""
+		if requestPayer {
+			want = "requester"
+		}
+		for _, r := range stub.reqs {
+			if got := r.Header.Get("X-Amz-Request-Payer"); got != want {
+				t.Errorf("RequestPayer %v: %s %s sent header %q, expected %q", requestPayer, r.Method, r.URL, got, want)
+			}
+		}
+	}
+}
+
+func TestPutACLContentMD5(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.PutBucketAcl(ctx, "bucket", acle); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.PutObjectACLWithOptions(ctx, "bucket", "no-md5", acle, PutObjectACLOptions{ACLOptions: ACLOptions{DisableContentMD5: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.PutObjectACLCanned(ctx, "bucket", "canned", "private"); err != nil {
+		t.Fatal(err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if len(stub.reqs) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(stub.reqs))
+	}
+	for _, r := range stub.reqs[:2] {
+		body := stub.acls[r.URL.Path+"?versionId="]
+		if got, want := r.Header.Get("Content-Md5"), sumMD5Base64([]byte(body)); got != want {
+			t.Errorf("%s: expected Content-MD5 %q, got %q", r.URL.Path, want, got)
+		}
+	}
+	for _, r := range stub.reqs[2:] {
+		if got := r.Header.Get("Content-Md5"); got != "" {
+			t.Errorf("%s: expected no Content-MD5, got %q", r.URL.Path, got)
+		}
+	}
+}
+
+func TestPutObjectACLAndGet(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.ServeHTTP(w, r)
+		if r.Method == http.MethodPut {
+			// The server materializes an extra grant for the bucket owner.
+			stub.mu.Lock()
+			stub.acls["/bucket/object?versionId="] = testACLXML(
+				testUserGrantXML("owner", "FULL_CONTROL"),
+				testUserGrantXML("bucket-owner", "READ"),
+			)
+			stub.mu.Unlock()
+		}
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	objInfo, err := clnt.PutObjectACLAndGet(context.Background(), "bucket", "object", acle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objInfo.Grant) != 2 || objInfo.Grant[1].Grantee.ID != "bucket-owner" {
+		t.Fatalf("expected the server's view of the ACL, got %+v", objInfo.Grant)
+	}
+	if objInfo.ETag == "" {
+		t.Fatal("expected the object stat info to be filled")
+	}
+
+	// A failed PUT skips the GET.
+	stub.mu.Lock()
+	stub.reqs = nil
+	stub.mu.Unlock()
+	if _, err = clnt.PutObjectACLAndGet(context.Background(), "bucket", "object", nil); err == nil {
+		t.Fatal("expected an error for a nil policy")
+	}
+	if n := stub.count(http.MethodGet); n != 0 {
+		t.Fatalf("expected no GET request, got %d", n)
+	}
+}
+
+func TestPutObjectACLGrants(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	for _, header := range []string{
+		"X-Amz-Grant-Read",
+		"x-amz-grant-write",
+		"X-Amz-Grant-Read-Acp",
+		"x-amz-grant-write-acp",
+		"X-Amz-Grant-Full-Control",
+	} {
+		stub.mu.Lock()
+		stub.reqs = nil
+		stub.mu.Unlock()
+
+		grantees := []string{`id="owner"`, `uri="` + GroupAllUsers + `"`}
+		if err := clnt.PutObjectACLGrants(ctx, "bucket", "object", map[string][]string{header: grantees}); err != nil {
+			t.Fatalf("%s: %v", header, err)
+		}
+		if len(stub.reqs) != 1 {
+			t.Fatalf("%s: expected 1 request, got %d", header, len(stub.reqs))
+		}
+		r := stub.reqs[0]
+		if r.Method != http.MethodPut || r.ContentLength != 0 {
+			t.Errorf("%s: expected a PUT without body, got %s with %d bytes", header, r.Method, r.ContentLength)
+		}
+		if got, want := r.Header.Get(header), strings.Join(grantees, ", "); got != want {
+			t.Errorf("%s: expected header value %q, got %q", header, want, got)
+		}
+	}
+
+	// The ACL metadata of GetObjectACL can be sent back as is.
+	stub.acls["/bucket/custom?versionId="] = testACLXML(
+		testUserGrantXML("owner", "FULL_CONTROL"), testUserGrantXML("reader", "READ"))
+	objInfo, err := clnt.GetObjectACLWithOptions(ctx, "bucket", "custom", GetObjectACLOptions{SkipStat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = clnt.PutObjectACLGrants(ctx, "bucket", "object", objInfo.Metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPutObjectACLGrantsInvalid(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	testCases := []map[string][]string{
+		nil,
+		{"X-Amz-Grant-Delete": {`id="owner"`}},
+		{"Content-Type": {"text/plain"}},
+		{"X-Amz-Grant-Read": nil},
+		{"X-Amz-Acl": {"public"}},
+	}
+	for i, grants := range testCases {
+		err := clnt.PutObjectACLGrants(context.Background(), "bucket", "object", grants)
+		if ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("Test %d: expected InvalidArgument, got %v", i+1, err)
+		}
+	}
+	if len(stub.reqs) != 0 {
+		t.Fatalf("expected no request, got %d", len(stub.reqs))
+	}
+}
+
+func TestPutObjectACLGrantsCannedConflict(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	err := clnt.PutObjectACLGrants(context.Background(), "bucket", "object", map[string][]string{
+		"x-amz-acl":                {"public-read"},
+		"X-Amz-Grant-Write":        {`id="writer"`},
+		"X-Amz-Grant-Full-Control": {`id="owner"`},
+	})
+	errResp := ToErrorResponse(err)
+	if errResp.Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	want := `Canned ACL "public-read" cannot be combined with the X-Amz-Grant-Full-Control, X-Amz-Grant-Write header(s).`
+	if errResp.Message != want {
+		t.Fatalf("expected message %q, got %q", want, errResp.Message)
+	}
+	if len(stub.reqs) != 0 {
+		t.Fatalf("expected no request, got %d", len(stub.reqs))
+	}
+
+	// A canned ACL alone is fine.
+	if err = clnt.PutObjectACLGrants(context.Background(), "bucket", "object", map[string][]string{"x-amz-acl": {"public-read"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPutObjectAclNamespace(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+	if err := clnt.PutObjectAcl(ctx, "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+	body := stub.acls["/bucket/object?versionId="]
+	if want := `<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`; !strings.HasPrefix(body, want) {
+		t.Fatalf("expected %s to start with %s", body, want)
+	}
+
+	// The namespaced document decodes back to the same policy.
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, removed, ownerChanged := ACLDiff(acld, acle); len(added)+len(removed) != 0 || ownerChanged {
+		t.Fatalf("round trip changed the policy: added %v, removed %v, owner changed %v", added, removed, ownerChanged)
+	}
+}
+
+func TestPresignObjectACL(t *testing.T) {
+	clnt, err := New("s3.amazonaws.com", &Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+		Secure: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	checkURL := func(u *url.URL, signedHeaders string) {
+		t.Helper()
+		query := u.Query()
+		if _, ok := query["acl"]; !ok || !strings.Contains(u.RawQuery, "acl=") {
+			t.Fatalf("expected an acl query in %s", u)
+		}
+		if len(query.Get("X-Amz-Signature")) != 64 {
+			t.Fatalf("expected a signature in %s", u)
+		}
+		if query.Get("X-Amz-Expires") != "3600" || query.Get("X-Amz-SignedHeaders") != signedHeaders {
+			t.Fatalf("unexpected presign query %s", u.RawQuery)
+		}
+	}
+
+	u, err := clnt.PresignGetObjectACL(ctx, "bucket", "object", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkURL(u, "host")
+
+	acl := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	aclBytes, err := xml.Marshal(acl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, header, err := clnt.PresignPutObjectACL(ctx, "bucket", "object", time.Hour, string(aclBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkURL(u, "content-md5;host")
+	if header.Get("Content-MD5") != sumMD5Base64(aclBytes) {
+		t.Fatalf("unexpected Content-MD5 %q", header.Get("Content-MD5"))
+	}
+
+	if _, _, err = clnt.PresignPutObjectACL(ctx, "bucket", "object", time.Hour, ""); ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestPutACLMaxGrants(t *testing.T) {
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	b := NewACLBuilder(Owner{ID: "owner"})
+	for i := 0; i < MaxACLGrants; i++ {
+		b.GrantCanonicalUser(fmt.Sprintf("user-%d", i), PermissionRead)
+	}
+	acle := b.Build()
+	if acle.GrantCount() != MaxACLGrants {
+		t.Fatalf("expected %d grants, got %d", MaxACLGrants, acle.GrantCount())
+	}
+	if err := clnt.PutObjectAcl(context.Background(), "bucket", "object", acle); err != nil {
+		t.Fatal(err)
+	}
+
+	acle = b.GrantCanonicalUser("user-100", PermissionRead).Build()
+	err := clnt.PutObjectAcl(context.Background(), "bucket", "object", acle)
+	if errResp := ToErrorResponse(err); errResp.Code != "InvalidArgument" || !strings.Contains(errResp.Message, "101 grants") {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	err = clnt.PutBucketAcl(context.Background(), "bucket", acle)
+	if errResp := ToErrorResponse(err); errResp.Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if n := stub.count(http.MethodPut); n != 1 {
+		t.Fatalf("expected a single PUT request, got %d", n)
+	}
+}
+
+func TestPutObjectACLDryRun(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acle := NewACLBuilder(Owner{ID: "owner"}).
+		GrantCanonicalUser("owner", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).Build()
+	expected, err := xml.Marshal(acle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := false
+	result, err := clnt.PutObjectACLWithResult(ctx, "bucket", "object", acle, PutObjectACLOptions{
+		DryRun:       true,
+		OnACLApplied: func(string, string, *AccessControlPolicyEncode) { applied = true },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || !bytes.Equal(result.Body, expected) {
+		t.Fatalf("expected a dry run with body %s, got %+v", expected, result)
+	}
+	if err = clnt.PutObjectACLstringWithOptions(ctx, "bucket", "object", string(expected), PutObjectACLOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 0 || applied {
+		t.Fatalf("expected no request, got %d requests (applied %v)", requests, applied)
+	}
+
+	// Validation still applies.
+	invalid := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", "ALL").Build()
+	if _, err = clnt.PutObjectACLWithResult(ctx, "bucket", "object", invalid, PutObjectACLOptions{DryRun: true}); ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	result, err = clnt.PutObjectACLWithResult(ctx, "bucket", "object", acle, PutObjectACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DryRun || !bytes.Equal(result.Body, expected) || requests != 1 {
+		t.Fatalf("expected the policy to be sent, got %+v and %d requests", result, requests)
+	}
+}
+
+func TestValidateACLXML(t *testing.T) {
+	escaped, err := xml.Marshal(NewACLBuilder(Owner{ID: "owner", DisplayName: "Tom & Jerry <TJ> ünicode"}).
+		GrantCanonicalUser("owner", PermissionFullControl).Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		xml   string
+		valid bool
+	}{
+		{string(escaped), true},
+		{testACLXML(testUserGrantXML("owner", PermissionFullControl)), true},
+		{`<AccessControlPolicy><Owner><ID>owner</ID><DisplayName>Tom & Jerry</DisplayName></Owner></AccessControlPolicy>`, false},
+		{"<AccessControlPolicy><Owner><DisplayName>\xff\xfe</DisplayName></Owner></AccessControlPolicy>", false},
+		{`<AccessControlPolicy><Owner></AccessControlPolicy>`, false},
+		{`<AccessControlPolicy/><AccessControlPolicy/>`, false},
+		{`<Tagging/>`, false},
+		{``, false},
+	}
+
+	stub := newACLTestServer()
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	for i, testCase := range testCases {
+		err := ValidateACLXML(testCase.xml)
+		if (err == nil) != testCase.valid {
+			t.Errorf("Test %d: expected valid %v, got %v", i+1, testCase.valid, err)
+		}
+		if err != nil && ToErrorResponse(err).Code != "InvalidArgument" {
+			t.Errorf("Test %d: expected InvalidArgument, got %v", i+1, err)
+		}
+		objErr := clnt.PutObjectACLstring(context.Background(), "bucket", "object", testCase.xml)
+		bucketErr := clnt.PutBucketACLstring(context.Background(), "bucket", testCase.xml)
+		if (objErr == nil) != testCase.valid || (bucketErr == nil) != testCase.valid {
+			t.Errorf("Test %d: expected valid %v, got %v and %v", i+1, testCase.valid, objErr, bucketErr)
+		}
+	}
+	if n := stub.count(http.MethodPut); n != 4 {
+		t.Fatalf("expected only the valid documents to be sent, got %d PUT requests", n)
+	}
+}
+
+func TestPutACLObjectLocked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>ObjectLocked</Code><Message>Object is WORM protected and cannot be overwritten</Message><Key>object</Key><BucketName>bucket</BucketName><Resource>/bucket/object</Resource></Error>`))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	err := clnt.PutObjectACLCanned(context.Background(), "bucket", "object", "private")
+	if !errors.Is(err, ErrObjectLockACLImmutable) {
+		t.Fatalf("expected ErrObjectLockACLImmutable, got %v", err)
+	}
+	if errors.Is(err, ErrMalformedACL) || ToErrorResponse(err).Code != "ObjectLocked" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	results := clnt.SetObjectsACLCanned(context.Background(), "bucket", []string{"a", "b"}, "private", BatchACLOptions{})
+	for res := range results {
+		if !errors.Is(res.Err, ErrObjectLockACLImmutable) {
+			t.Errorf("%s: expected ErrObjectLockACLImmutable, got %v", res.ObjectName, res.Err)
+		}
+	}
+}
+
+func TestPutObjectACLAutoCorrectOwner(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))
+	var puts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			body, _ := ioutil.ReadAll(r.Body)
+			puts = append(puts, string(body))
+			if !strings.Contains(string(body), "<Owner><ID>owner</ID>") {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied", Message: "Owner does not match."}))
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+	copied := NewACLBuilder(Owner{ID: "other-account"}).
+		GrantCanonicalUser("other-account", PermissionFullControl).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		Build()
+
+	// Without the option the error is returned as is.
+	err := clnt.PutObjectACLWithOptions(ctx, "bucket", "object", copied, PutObjectACLOptions{})
+	if ToErrorResponse(err).Code != "AccessDenied" || len(puts) != 1 {
+		t.Fatalf("expected a single denied PUT, got %v after %d PUT requests", err, len(puts))
+	}
+
+	puts = nil
+	var applied *AccessControlPolicyEncode
+	result, err := clnt.PutObjectACLWithResult(ctx, "bucket", "object", copied, PutObjectACLOptions{
+		AutoCorrectOwner: true,
+		OnACLApplied: func(_, _ string, policy *AccessControlPolicyEncode) {
+			applied = policy
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(puts) != 2 {
+		t.Fatalf("expected the PUT to be retried once, got %d PUT requests", len(puts))
+	}
+	if string(result.Body) != puts[1] || applied == nil || applied.Owner.ID != "owner" {
+		t.Fatalf("expected the corrected policy to be reported, got %s and %+v", result.Body, applied)
+	}
+	if copied.Owner.ID != "other-account" {
+		t.Fatalf("the policy of the caller was modified: %+v", copied.Owner)
+	}
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acld.Owner.ID != "owner" || !hasAllUsersGrant(acld, PermissionRead) {
+		t.Fatalf("unexpected ACL after the retry %+v", acld)
+	}
+
+	// A denial unrelated to the owner is not retried.
+	puts = nil
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts = append(puts, "")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(encodeResponse(ErrorResponse{Code: "AccessDenied"}))
+			return
+		}
+		stub.ServeHTTP(w, r)
+	})
+	owned := NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	err = clnt.PutObjectACLWithOptions(ctx, "bucket", "object", owned, PutObjectACLOptions{AutoCorrectOwner: true})
+	if ToErrorResponse(err).Code != "AccessDenied" || len(puts) != 1 {
+		t.Fatalf("expected a single denied PUT, got %v after %d PUT requests", err, len(puts))
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// defaultUpdateACLRetries is the number of times UpdateObjectACL retries
+// after a conflicting update when UpdateACLOptions.MaxRetries is zero.
+const defaultUpdateACLRetries = 3
+
+// UpdateACLOptions holds options for UpdateObjectACL.
+type UpdateACLOptions struct {
+	ACLOptions
+
+	// VersionID selects the object version whose ACL is updated.
+	VersionID string
+
+	// MaxRetries is the number of times the read-modify-write cycle is
+	// retried when the server rejects the update with 412 Precondition
+	// Failed. Zero means 3, a negative value disables retries. It
+	// shadows ACLOptions.MaxRetries, which applies to transient errors
+	// of every single request.
+	MaxRetries int
+
+	// RequestPayer acknowledges that the requester is charged for the
+	// requests, which requester-pays buckets require.
+	RequestPayer bool
+}
+
+// UpdateObjectACL reads the ACL of an object, passes it to mutate and
+// writes the result back. When the server returns an ETag along with
+// the ACL, the update is sent with If-Match so a concurrent change makes
+// it fail with 412 Precondition Failed; the whole cycle, mutate
+// included, is then retried on the fresh ACL. An error returned by
+// mutate aborts the update.
+func (c *Client) UpdateObjectACL(ctx context.Context, bucketName, objectName string, mutate func(*AccessControlPolicyEncode) error, opts UpdateACLOptions) error {
+	// Input validation.
+	if err := s3utils.CheckValidBucketName(bucketName); err != nil {
+		return err
+	}
+	if err := s3utils.CheckValidObjectName(objectName); err != nil {
+		return err
+	}
+	if mutate == nil {
+		return errInvalidArgument("ACL mutate function cannot be nil.")
+	}
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+	return c.updateACL(ctx, bucketName, objectName, mutate, opts)
+}
+
+// errACLUnchanged may be returned by an internal mutate function to end
+// the update successfully without sending the ACL back.
+var errACLUnchanged = errors.New("ACL unchanged")
+
+// updateACL runs the read-modify-write cycle of UpdateObjectACL on a
+// bucket, or on an object when objectName is non-empty.
+func (c *Client) updateACL(ctx context.Context, bucketName, objectName string, mutate func(*AccessControlPolicyEncode) error, opts UpdateACLOptions) error {
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultUpdateACLRetries
+	}
+	for attempt := 0; ; attempt++ {
+		err := c.updateACLOnce(ctx, bucketName, objectName, mutate, opts)
+		if err == errACLUnchanged {
+			return nil
+		}
+		if err == nil || attempt >= maxRetries {
+			return err
+		}
+		if ToErrorResponse(err).StatusCode != http.StatusPreconditionFailed {
+			return err
+		}
+	}
+}
+
+// updateACLOnce runs a single read-modify-write cycle of updateACL.
+func (c *Client) updateACLOnce(ctx context.Context, bucketName, objectName string, mutate func(*AccessControlPolicyEncode) error, opts UpdateACLOptions) error {
+	acld, respHeader, err := c.getACLPolicyHeader(ctx, bucketName, objectName, opts.VersionID, requestPayerHeader(nil, opts.RequestPayer), opts.ACLOptions)
+	if err != nil {
+		return err
+	}
+	etag := respHeader.Get("ETag")
+	acle := policyDecodeToEncode(acld)
+	if err = mutate(acle); err != nil {
+		return err
+	}
+	if err = acle.validate(); err != nil {
+		return err
+	}
+	aclBytes, err := opts.marshalPolicy(acle)
+	if err != nil {
+		return err
+	}
+	customHeader := requestPayerHeader(nil, opts.RequestPayer)
+	if etag != "" {
+		if customHeader == nil {
+			customHeader = make(http.Header)
+		}
+		customHeader.Set("If-Match", etag)
+	}
+	return c.putACL(ctx, bucketName, objectName, opts.VersionID, customHeader, string(aclBytes), opts.ACLOptions)
+}
+
+// EnsureObjectACL sets the ACL of an object to desired unless the
+// current ACL already holds the same owner and the same set of grants,
+// see ACLDiff. An empty desired owner keeps the current one. It reports
+// whether the ACL was written.
+func (c *Client) EnsureObjectACL(ctx context.Context, bucketName, objectName string, desired *AccessControlPolicyEncode) (changed bool, err error) {
+	// Input validation.
+	if err = s3utils.CheckValidBucketName(bucketName); err != nil {
+		return false, err
+	}
+	if err = s3utils.CheckValidObjectName(objectName); err != nil {
+		return false, err
+	}
+	if desired == nil {
+		return false, errInvalidArgument("ACL policy cannot be nil.")
+	}
+	if err = desired.validate(); err != nil {
+		return false, err
+	}
+
+	err = c.updateACL(ctx, bucketName, objectName, func(acle *AccessControlPolicyEncode) error {
+		added, removed, ownerChanged := ACLDiff(policyEncodeToDecode(acle), desired)
+		changed = len(added) > 0 || len(removed) > 0 || ownerChanged
+		if !changed {
+			return errACLUnchanged
+		}
+		if desired.Owner.ID != "" {
+			acle.Owner = desired.Owner
+		}
+		acle.AccessControlList.Grants = append([]GrantEncode(nil), desired.AccessControlList.Grants...)
+		return nil
+	}, UpdateACLOptions{})
+	if err != nil {
+		return false, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,257 @@
+/*
+ * MinIO Go Library for Amazon S3 Compatible Cloud Storage
+ * Copyright 2022 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUpdateObjectACLRetriesOnConflict(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(testUserGrantXML("75aa57f09aa0c8caeab4f8c24e99d10f8e7faeebf76c078efc7c6caea54ba06a", "FULL_CONTROL"))
+	var puts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `"acl-etag"`)
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != `"acl-etag"` {
+				t.Errorf("unexpected If-Match %q", r.Header.Get("If-Match"))
+			}
+			if atomic.AddInt32(&puts, 1) == 1 {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	calls := 0
+	err := clnt.UpdateObjectACL(context.Background(), "bucket", "object", func(acle *AccessControlPolicyEncode) error {
+		calls++
+		acle.AddGrant(GrantEncode{
+			Grantee:    GranteeEncode{Type: GranteeTypeGroup, URI: GroupAllUsers},
+			Permission: PermissionRead,
+		})
+		return nil
+	}, UpdateACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected mutate to run twice, ran %d times", calls)
+	}
+	if puts != 2 {
+		t.Fatalf("expected 2 PUT requests, got %d", puts)
+	}
+	acld, err := clnt.getACLPolicy(context.Background(), "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(acld.AccessControlList.Grants); n != 2 {
+		t.Fatalf("expected 2 grants, got %d", n)
+	}
+}
+
+func TestUpdateObjectACLErrors(t *testing.T) {
+	var puts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			atomic.AddInt32(&puts, 1)
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Write([]byte(testACLXML(testUserGrantXML("owner", "FULL_CONTROL"))))
+	}))
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+
+	// Retries are bounded.
+	err := clnt.UpdateObjectACL(context.Background(), "bucket", "object", func(*AccessControlPolicyEncode) error {
+		return nil
+	}, UpdateACLOptions{MaxRetries: 2})
+	if ToErrorResponse(err).StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 error, got %v", err)
+	}
+	if puts != 3 {
+		t.Fatalf("expected 3 PUT requests, got %d", puts)
+	}
+
+	// A mutate error aborts the update without any PUT.
+	atomic.StoreInt32(&puts, 0)
+	errAbort := errors.New("abort")
+	err = clnt.UpdateObjectACL(context.Background(), "bucket", "object", func(*AccessControlPolicyEncode) error {
+		return errAbort
+	}, UpdateACLOptions{})
+	if err != errAbort {
+		t.Fatalf("expected %v, got %v", errAbort, err)
+	}
+	if puts != 0 {
+		t.Fatalf("expected no PUT request, got %d", puts)
+	}
+}
+
+func TestACLExtensionsRoundTrip(t *testing.T) {
+	vendorTag := `<VendorTag priority="1"><Level>gold</Level></VendorTag>`
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = strings.Replace(
+		testACLXML(testUserGrantXML("owner", "FULL_CONTROL")),
+		"</AccessControlPolicy>", vendorTag+"</AccessControlPolicy>", 1)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	acld, err := clnt.getACLPolicy(ctx, "bucket", "object", "", nil, ACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acld.Extensions) != 1 || acld.Extensions[0].XMLName.Local != "VendorTag" {
+		t.Fatalf("unexpected extensions %+v", acld.Extensions)
+	}
+
+	err = clnt.UpdateObjectACL(ctx, "bucket", "object", func(*AccessControlPolicyEncode) error {
+		return nil
+	}, UpdateACLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clnt.CopyObjectACL(ctx, "bucket", "object", "bucket", "copy"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"/bucket/object?versionId=", "/bucket/copy?versionId="} {
+		acl := stub.acls[key]
+		for _, want := range []string{`<VendorTag`, `priority="1"`, `<Level>gold</Level></VendorTag>`} {
+			if !strings.Contains(acl, want) {
+				t.Errorf("%s: %s does not contain %s", key, acl, want)
+			}
+		}
+		// The extension still decodes after the round trip.
+		res := &AccessControlPolicyDecode{}
+		if err := xmlDecoder(strings.NewReader(acl), res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Extensions) != 1 || res.Extensions[0].InnerXML != "<Level>gold</Level>" {
+			t.Errorf("%s: unexpected extensions %+v", key, res.Extensions)
+		}
+	}
+}
+
+func TestACLExtensionsNamespaceRoundTrip(t *testing.T) {
+	testCases := []struct {
+		vendorTag string
+		want      string
+	}{
+		{`<VendorTag xmlns="urn:vendor" a="1"><Level>gold</Level></VendorTag>`, `<VendorTag xmlns="urn:vendor" a="1"><Level>gold</Level></VendorTag>`},
+		{`<VendorTag xmlns="urn:vendor" xmlns:v="urn:v" a="1" v:b="2"><v:Level>gold</v:Level></VendorTag>`, `<VendorTag xmlns="urn:vendor" xmlns:v="urn:v" a="1" v:b="2"><v:Level>gold</v:Level></VendorTag>`},
+		// The inherited S3 namespace is declared explicitly.
+		{`<VendorTag a="1">gold</VendorTag>`, `<VendorTag xmlns="http://s3.amazonaws.com/doc/2006-03-01/" a="1">gold</VendorTag>`},
+	}
+	for i, testCase := range testCases {
+		acld := &AccessControlPolicyDecode{}
+		body := strings.Replace(testACLXML(testUserGrantXML("owner", "FULL_CONTROL")), "</AccessControlPolicy>", testCase.vendorTag+"</AccessControlPolicy>", 1)
+		if err := xmlDecoder(strings.NewReader(body), acld); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		buf, err := xml.Marshal(policyDecodeToEncode(acld))
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !strings.Contains(string(buf), testCase.want) {
+			t.Errorf("Test %d: %s does not contain %s", i+1, buf, testCase.want)
+		}
+		// Go's decoder accepts duplicate attributes, check them by hand.
+		d := xml.NewDecoder(bytes.NewReader(buf))
+		for {
+			tok, err := d.RawToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Test %d: %v", i+1, err)
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			seen := make(map[xml.Name]bool)
+			for _, attr := range start.Attr {
+				if seen[attr.Name] {
+					t.Errorf("Test %d: duplicate attribute %v in %s", i+1, attr.Name, buf)
+				}
+				seen[attr.Name] = true
+			}
+		}
+	}
+}
+
+func TestEnsureObjectACL(t *testing.T) {
+	stub := newACLTestServer()
+	stub.acls["/bucket/object?versionId="] = testACLXML(
+		testUserGrantXML("owner", PermissionFullControl),
+		testGroupGrantXML(GroupAllUsers, PermissionRead),
+	)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	clnt := newACLTestClient(t, srv)
+	ctx := context.Background()
+
+	// Same grants in another order.
+	desired := NewACLBuilder(Owner{ID: "owner"}).
+		GrantGroup(GroupAllUsers, PermissionRead).
+		GrantCanonicalUser("owner", PermissionFullControl).Build()
+	changed, err := clnt.EnsureObjectACL(ctx, "bucket", "object", desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || stub.count(http.MethodPut) != 0 {
+		t.Fatalf("expected no PUT, got changed %v and %d PUT requests", changed, stub.count(http.MethodPut))
+	}
+
+	desired = NewACLBuilder(Owner{ID: "owner"}).GrantCanonicalUser("owner", PermissionFullControl).Build()
+	changed, err = clnt.EnsureObjectACL(ctx, "bucket", "object", desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || stub.count(http.MethodPut) != 1 {
+		t.Fatalf("expected a PUT, got changed %v and %d PUT requests", changed, stub.count(http.MethodPut))
+	}
+	if public, err := clnt.IsObjectPublic(ctx, "bucket", "object"); err != nil || public {
+		t.Fatalf("expected a private object, got %v, %v", public, err)
+	}
+
+	// Applying it again is a no-op.
+	if changed, err = clnt.EnsureObjectACL(ctx, "bucket", "object", desired); err != nil || changed {
+		t.Fatalf("expected no change, got %v, %v", changed, err)
+	}
+	if _, err = clnt.EnsureObjectACL(ctx, "bucket", "object", nil); ToErrorResponse(err).Code != "InvalidArgument" {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
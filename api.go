@@ -71,6 +71,7 @@ type Client struct {
 	// Needs allocation.
 	httpClient     *http.Client
 	bucketLocCache *bucketLocationCache
+	bucketACLCache *ACLCache
 
 	// Advanced functionality.
 	isTraceEnabled  bool
@@ -114,6 +115,11 @@ type Options struct {
 	// Custom hash routines. Leave nil to use standard.
 	CustomMD5    func() md5simd.Hasher
 	CustomSHA256 func() md5simd.Hasher
+
+	// BucketACLCacheTTL, when non-zero, caches the decoded bucket ACLs
+	// read by GetBucketACL and IsBucketPublic for this duration. Bucket
+	// ACL changes made through the client invalidate the cache.
+	BucketACLCacheTTL time.Duration
 }
 
 // Global constants.
@@ -242,6 +248,11 @@ func privateNew(endpoint string, opts *Options) (*Client, error) {
 	// Instantiate bucket location cache.
 	clnt.bucketLocCache = newBucketLocationCache()
 
+	// Instantiate bucket ACL cache if requested.
+	if opts.BucketACLCacheTTL > 0 {
+		clnt.bucketACLCache = NewACLCache(clnt, opts.BucketACLCacheTTL, bucketACLCacheSize)
+	}
+
 	// Introduce a new locked random seed.
 	clnt.random = rand.New(&lockedRandSource{src: rand.NewSource(time.Now().UTC().UnixNano())})
 
@@ -432,6 +443,10 @@ type requestMetadata struct {
 	streamSha256     bool
 	addCrc           bool
 	trailer          http.Header // (http.Request).Trailer. Requires v4 signature.
+
+	// Override MaxRetry and DefaultRetryUnit when non-zero.
+	maxRetry  int
+	retryUnit time.Duration
 }
 
 // dumpHTTP - dump HTTP request and response.
@@ -553,6 +568,16 @@ func (c *Client) executeMethod(ctx context.Context, method string, metadata requ
 	var retryable bool       // Indicates if request can be retried.
 	var bodySeeker io.Seeker // Extracted seeker from io.Reader.
 	reqRetry := MaxRetry     // Indicates how many times we can retry the request
+	if metadata.maxRetry > 0 {
+		reqRetry = metadata.maxRetry
+	}
+	retryUnit, retryCap := DefaultRetryUnit, DefaultRetryCap
+	if metadata.retryUnit > 0 {
+		retryUnit = metadata.retryUnit
+		if retryUnit > retryCap {
+			retryCap = retryUnit
+		}
+	}
 
 	if metadata.contentBody != nil {
 		// Check if body is seekable then it is retryable.
@@ -581,7 +606,7 @@ func (c *Client) executeMethod(ctx context.Context, method string, metadata requ
 	// Indicate to our routine to exit cleanly upon return.
 	defer cancel()
 
-	for range c.newRetryTimer(retryCtx, reqRetry, DefaultRetryUnit, DefaultRetryCap, MaxJitter) {
+	for range c.newRetryTimer(retryCtx, reqRetry, retryUnit, retryCap, MaxJitter) {
 		// Retry executes the following function body if request has an
 		// error until maxRetries have been exhausted, retry attempts are
 		// performed after waiting for a given period of time in a
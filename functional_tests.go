@@ -11757,6 +11757,82 @@ func testGetObjectACLContext() {
 	successLogger(testName, function, args, startTime).Info()
 }
 
+// Test PutObjectAcl sends an AccessControlPolicy the server accepts.
+func testPutObjectACL() {
+	// initialize logging params
+	startTime := time.Now()
+	testName := getFuncName()
+	function := "PutObjectAcl(ctx, bucketName, objectName, acl)"
+	args := map[string]interface{}{
+		"bucketName": "",
+		"objectName": "",
+		"acl":        "",
+	}
+	// Seed random based on current time.
+	rand.Seed(time.Now().Unix())
+
+	// Instantiate new minio client object.
+	c, err := minio.New(os.Getenv(serverEndpoint),
+		&minio.Options{
+			Creds:  credentials.NewStaticV4(os.Getenv(accessKey), os.Getenv(secretKey), ""),
+			Secure: mustParseBool(os.Getenv(enableHTTPS)),
+		})
+	if err != nil {
+		logError(testName, function, args, startTime, "", "MinIO client v4 object creation failed", err)
+		return
+	}
+
+	// Set user agent.
+	c.SetAppInfo("MinIO-go-FunctionalTest", "0.1.0")
+
+	// Generate a new random bucket name.
+	bucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "minio-go-test-")
+	args["bucketName"] = bucketName
+
+	// Make a new bucket.
+	err = c.MakeBucket(context.Background(), bucketName, minio.MakeBucketOptions{Region: "us-east-1"})
+	if err != nil {
+		logError(testName, function, args, startTime, "", "MakeBucket failed", err)
+		return
+	}
+
+	defer cleanupBucket(bucketName, c)
+
+	objectName := randString(60, rand.NewSource(time.Now().UnixNano()), "")
+	args["objectName"] = objectName
+
+	_, err = c.PutObject(context.Background(), bucketName, objectName, strings.NewReader("acl"), 3, minio.PutObjectOptions{})
+	if err != nil {
+		logError(testName, function, args, startTime, "", "PutObject failed", err)
+		return
+	}
+
+	owner, err := c.GetObjectOwner(context.Background(), bucketName, objectName)
+	if err != nil {
+		logError(testName, function, args, startTime, "", "GetObjectOwner failed", err)
+		return
+	}
+
+	acl := minio.NewACLBuilder(owner).GrantCanonicalUser(owner.ID, minio.PermissionFullControl).Build()
+	args["acl"] = acl
+	if err = c.PutObjectAcl(context.Background(), bucketName, objectName, acl); err != nil {
+		logError(testName, function, args, startTime, "", "PutObjectAcl failed", err)
+		return
+	}
+
+	objectInfo, err := c.GetObjectACL(context.Background(), bucketName, objectName)
+	if err != nil {
+		logError(testName, function, args, startTime, "", "GetObjectACL failed", err)
+		return
+	}
+	if s := objectInfo.Metadata.Get("X-Amz-Acl"); s != "private" {
+		logError(testName, function, args, startTime, "", "GetObjectACL fail \"X-Amz-Acl\" expected \"private\" but got "+fmt.Sprintf("%q", s), nil)
+		return
+	}
+
+	successLogger(testName, function, args, startTime).Info()
+}
+
 // Test validates putObject with context to see if request cancellation is honored for V2.
 func testPutObjectContextV2() {
 	// initialize logging params
@@ -12330,6 +12406,7 @@ func main() {
 		testFPutObjectContext()
 		testFGetObjectContext()
 		testGetObjectACLContext()
+		testPutObjectACL()
 		testPutObjectContext()
 		testStorageClassMetadataPutObject()
 		testStorageClassInvalidMetadataPutObject()